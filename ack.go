@@ -0,0 +1,274 @@
+package splunk
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+	"github.com/mundipagg/tracer-splunk-writer/pending"
+)
+
+const (
+	DefaultAckInterval = 10 * time.Second
+	DefaultAckTimeout  = 5 * time.Minute
+)
+
+type Ack struct {
+	Enabled  bool
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+type AckTimeoutError struct {
+	ID      int64
+	Timeout time.Duration
+}
+
+func (e *AckTimeoutError) Error() string {
+	return fmt.Sprintf("batch %v was not indexed after %v", e.ID, e.Timeout)
+}
+
+type acknowledgment struct {
+	address string
+	id      int64
+}
+
+type unacked struct {
+	events   []interface{}
+	bytes    int
+	deadline time.Time
+	held     string
+}
+
+type acknowledger struct {
+	sync.Mutex
+	Ack
+	waiting map[acknowledgment]unacked
+	pending pending.Counter
+	once    sync.Once
+	done    chan struct{}
+}
+
+func newAcknowledger(config Ack) *acknowledger {
+	if config.Interval <= 0 {
+		config.Interval = DefaultAckInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultAckTimeout
+	}
+	return &acknowledger{
+		Ack:     config,
+		waiting: map[acknowledgment]unacked{},
+		done:    make(chan struct{}),
+	}
+}
+
+func collectorAddress(address string, name string) string {
+	u, err := url.Parse(address)
+	if err != nil {
+		return address
+	}
+	u.RawQuery = ""
+	path := strings.TrimSuffix(u.Path, "/")
+	switch {
+	case strings.HasSuffix(path, "/event"):
+		path = strings.TrimSuffix(path, "/event")
+	case strings.HasSuffix(path, "/raw"):
+		path = strings.TrimSuffix(path, "/raw")
+	}
+	u.Path = path + "/" + name
+	return u.String()
+}
+
+func parseAckID(response *http.Response) (int64, bool) {
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return 0, false
+	}
+	var parsed struct {
+		AckID *int64 `json:"ackId"`
+	}
+	if jsoniter.Unmarshal(body, &parsed) != nil || parsed.AckID == nil {
+		return 0, false
+	}
+	return *parsed.AckID, true
+}
+
+func (sw *Writer) confirm(address string, response *http.Response, events []interface{}, bytes int) bool {
+	if sw.acks == nil {
+		return false
+	}
+	id, ok := parseAckID(response)
+	if !ok {
+		return false
+	}
+	a := sw.acks
+	a.pending.Add(len(events))
+	batch := unacked{
+		events:   events,
+		bytes:    bytes,
+		deadline: time.Now().Add(a.Timeout),
+	}
+	if spooler, ok := sw.buffer.(buffer.Spooler); ok {
+		held, err := spooler.Hold(events)
+		if err != nil {
+			sw.report(ErrorEvent{Stage: buffer.StageSpool, BatchSize: len(events), Err: err})
+		}
+		batch.held = held
+	}
+	a.Lock()
+	a.waiting[acknowledgment{address: collectorAddress(address, "ack"), id: id}] = batch
+	a.Unlock()
+	a.once.Do(func() {
+		go sw.poll()
+	})
+	return true
+}
+
+func (sw *Writer) poll() {
+	a := sw.acks
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := sw.query(); err != nil {
+				sw.report(ErrorEvent{Stage: buffer.StageAck, Err: err})
+			}
+			sw.expire()
+		case <-a.done:
+			return
+		}
+	}
+}
+
+func (sw *Writer) expire() {
+	a := sw.acks
+	now := time.Now()
+	expired := map[acknowledgment]unacked{}
+	a.Lock()
+	for ack, batch := range a.waiting {
+		if now.After(batch.deadline) {
+			expired[ack] = batch
+			delete(a.waiting, ack)
+		}
+	}
+	a.Unlock()
+	for ack, batch := range expired {
+		sw.report(ErrorEvent{
+			Stage:     buffer.StageAck,
+			BatchSize: len(batch.events),
+			Err:       &AckTimeoutError{ID: ack.id, Timeout: a.Timeout},
+		})
+		if !sw.requeue(batch) {
+			for _, event := range batch.events {
+				sw.buffer.Write(event)
+			}
+		}
+		a.pending.Done(len(batch.events))
+	}
+}
+
+func (sw *Writer) release(batch unacked) {
+	if spooler, ok := sw.buffer.(buffer.Spooler); ok {
+		spooler.Release(batch.held)
+	}
+}
+
+func (sw *Writer) requeue(batch unacked) bool {
+	spooler, ok := sw.buffer.(buffer.Spooler)
+	if !ok || len(batch.held) == 0 {
+		return false
+	}
+	if err := spooler.Requeue(batch.held); err != nil {
+		sw.report(ErrorEvent{Stage: buffer.StageSpool, BatchSize: len(batch.events), Err: err})
+		return false
+	}
+	return true
+}
+
+func (sw *Writer) query() error {
+	a := sw.acks
+	a.Lock()
+	ids := map[string][]int64{}
+	for ack := range a.waiting {
+		ids[ack.address] = append(ids[ack.address], ack.id)
+	}
+	a.Unlock()
+	var failure error
+	for address, pending := range ids {
+		if err := sw.queryAddress(address, pending); err != nil {
+			failure = err
+		}
+	}
+	return failure
+}
+
+func (sw *Writer) queryAddress(address string, ids []int64) error {
+	a := sw.acks
+	body, err := jsoniter.Marshal(map[string][]int64{"acks": ids})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, address, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	if len(sw.key) > 0 {
+		request.Header.Set("Authorization", "Splunk "+sw.key)
+	}
+	request.Header.Set(ChannelHeader, sw.channel)
+	request.Header.Set("Content-Type", "application/json")
+	response, err := sw.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != 200 {
+		return newHECError(response)
+	}
+	var parsed struct {
+		Acks map[string]bool `json:"acks"`
+	}
+	body, err = ioutil.ReadAll(response.Body)
+	if err == nil {
+		err = jsoniter.Unmarshal(body, &parsed)
+	}
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if !parsed.Acks[fmt.Sprint(id)] {
+			continue
+		}
+		ack := acknowledgment{address: address, id: id}
+		a.Lock()
+		batch, ok := a.waiting[ack]
+		delete(a.waiting, ack)
+		a.Unlock()
+		if ok {
+			sw.release(batch)
+			sw.stats.success(len(batch.events), batch.bytes)
+			a.pending.Done(len(batch.events))
+		}
+	}
+	return nil
+}
+
+func (a *acknowledger) unacked() int {
+	if a == nil {
+		return 0
+	}
+	return a.pending.Len()
+}
+
+func (a *acknowledger) stop() {
+	close(a.done)
+}
@@ -0,0 +1,270 @@
+package splunk
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/icrowley/fake"
+	"github.com/jarcoal/httpmock"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+	"github.com/mundipagg/tracer-splunk-writer/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCollectorAddress(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"http://localhost:8088/services/collector":                  "http://localhost:8088/services/collector/ack",
+		"http://localhost:8088/services/collector/":                 "http://localhost:8088/services/collector/ack",
+		"http://localhost:8088/services/collector/event":            "http://localhost:8088/services/collector/ack",
+		"http://localhost:8088/services/collector/raw?index=main":   "http://localhost:8088/services/collector/ack",
+		"https://splunk.io/services/collector/event/1.0?channel=id": "https://splunk.io/services/collector/event/1.0/ack",
+	}
+	for address, expected := range cases {
+		address, expected := address, expected
+		t.Run("when the address is "+address, func(t *testing.T) {
+			t.Parallel()
+			is := assert.New(t)
+			is.Equal(expected, collectorAddress(address, "ack"), "it should return the ack endpoint")
+		})
+	}
+}
+
+func TestNewAcknowledger(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	subject := newAcknowledger(Ack{Enabled: true})
+	is.Equal(DefaultAckInterval, subject.Interval, "it should use the default interval")
+	is.Equal(DefaultAckTimeout, subject.Timeout, "it should use the default timeout")
+}
+
+func TestWriter_Send_Ack(t *testing.T) {
+	t.Parallel()
+	t.Run("when splunk confirms the indexing", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		c := &http.Client{}
+		activateNonDefault(c)
+		url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+		httpmock.RegisterResponder("POST", url+"/event", func(request *http.Request) (response *http.Response, err error) {
+			is.Equal("channel", request.Header.Get(ChannelHeader), "it should send the channel")
+			return httpmock.NewStringResponse(200, `{"text":"Success","code":0,"ackId":7}`), nil
+		})
+		var polls []string
+		httpmock.RegisterResponder("POST", url+"/ack", func(request *http.Request) (response *http.Response, err error) {
+			is.Equal("channel", request.Header.Get(ChannelHeader), "it should send the channel")
+			body, _ := ioutil.ReadAll(request.Body)
+			polls = append(polls, string(body))
+			if len(polls) == 1 {
+				return httpmock.NewStringResponse(200, `{"acks":{"7":false}}`), nil
+			}
+			return httpmock.NewStringResponse(200, `{"acks":{"7":true}}`), nil
+		})
+		buf := &buffer.Mock{}
+		buf.On("Flush", mock.Anything).Return(0, nil)
+		buf.On("Stats").Return(buffer.Stats{})
+		subject := &Writer{
+			address:    url + "/event",
+			client:     c,
+			marshaller: json.New(),
+			buffer:     buf,
+			channel:    "channel",
+			acks: newAcknowledger(Ack{
+				Interval: 20 * time.Millisecond,
+				Timeout:  time.Second,
+			}),
+		}
+		defer subject.acks.stop()
+		err := subject.send([]interface{}{1})
+		is.Nil(err, "it should return no error")
+		is.Equal(1, subject.Stats().QueueDepth, "it should release the batch before it is indexed")
+		is.Equal(uint64(0), subject.Stats().Events, "it should not count the event before it is indexed")
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		dropped, err := subject.Flush(ctx)
+		is.Nil(err, "it should wait for the indexing")
+		is.Equal(0, dropped, "it should drop nothing")
+		is.Equal([]string{`{"acks":[7]}`, `{"acks":[7]}`}, polls, "it should poll until the batch is indexed")
+		is.Equal(uint64(1), subject.Stats().Events, "it should count the event once indexed")
+	})
+	t.Run("when splunk does not confirm the indexing in time", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		c := &http.Client{}
+		activateNonDefault(c)
+		url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+		httpmock.RegisterResponder("POST", url+"/event", func(request *http.Request) (response *http.Response, err error) {
+			return httpmock.NewStringResponse(200, `{"text":"Success","code":0,"ackId":3}`), nil
+		})
+		httpmock.RegisterResponder("POST", url+"/ack", func(request *http.Request) (response *http.Response, err error) {
+			return httpmock.NewStringResponse(200, `{"acks":{"3":false}}`), nil
+		})
+		buf := &buffer.Mock{}
+		buf.On("Write", mock.Anything).Return()
+		var reported []ErrorEvent
+		subject := &Writer{
+			address:    url + "/event",
+			client:     c,
+			marshaller: json.New(),
+			buffer:     buf,
+			channel:    "channel",
+			acks: newAcknowledger(Ack{
+				Interval: 5 * time.Millisecond,
+				Timeout:  30 * time.Millisecond,
+			}),
+			errorHandler: func(e ErrorEvent) {
+				reported = append(reported, e)
+			},
+		}
+		defer subject.acks.stop()
+		is.Nil(subject.send([]interface{}{1, 2}), "it should return no error")
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		is.Nil(subject.acks.pending.Wait(ctx), "it should stop waiting for the batch")
+		is.Equal([]ErrorEvent{{
+			Stage:     buffer.StageAck,
+			BatchSize: 2,
+			Err:       &AckTimeoutError{ID: 3, Timeout: 30 * time.Millisecond},
+		}}, reported, "it should report the timeout")
+		buf.AssertCalled(t, "Write", 1)
+		buf.AssertCalled(t, "Write", 2)
+		is.Empty(subject.acks.waiting, "it should forget the batch")
+	})
+	t.Run("when splunk does not return an ack id", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		c := &http.Client{}
+		activateNonDefault(c)
+		url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+		httpmock.RegisterResponder("POST", url, func(request *http.Request) (response *http.Response, err error) {
+			return httpmock.NewStringResponse(200, `{"text":"Success","code":0}`), nil
+		})
+		subject := &Writer{
+			address:    url,
+			client:     c,
+			marshaller: json.New(),
+			acks:       newAcknowledger(Ack{}),
+		}
+		defer subject.acks.stop()
+		is.Nil(subject.send([]interface{}{1}), "it should consider the batch delivered")
+		is.Equal(0, subject.acks.unacked(), "it should not wait for the batch")
+	})
+}
+
+func TestWriter_Send_Ack_Spool(t *testing.T) {
+	t.Parallel()
+	held := func(dir string, pattern string) int {
+		files, _ := filepath.Glob(filepath.Join(dir, pattern))
+		return len(files)
+	}
+	t.Run("when splunk confirms the indexing", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		dir, _ := ioutil.TempDir("", "spool")
+		defer os.RemoveAll(dir)
+		c := &http.Client{}
+		activateNonDefault(c)
+		url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+		var sent, indexed int32
+		httpmock.RegisterResponder("POST", url+"/event", func(request *http.Request) (response *http.Response, err error) {
+			atomic.AddInt32(&sent, 1)
+			return httpmock.NewStringResponse(200, `{"text":"Success","code":0,"ackId":5}`), nil
+		})
+		httpmock.RegisterResponder("POST", url+"/ack", func(request *http.Request) (response *http.Response, err error) {
+			if atomic.LoadInt32(&indexed) == 1 {
+				return httpmock.NewStringResponse(200, `{"acks":{"5":true}}`), nil
+			}
+			return httpmock.NewStringResponse(200, `{"acks":{"5":false}}`), nil
+		})
+		subject := &Writer{
+			address:    url + "/event",
+			client:     c,
+			marshaller: json.New(),
+			channel:    "channel",
+			acks: newAcknowledger(Ack{
+				Interval: 5 * time.Millisecond,
+				Timeout:  time.Minute,
+			}),
+		}
+		defer subject.acks.stop()
+		subject.buffer = buffer.New(buffer.Config{
+			OnOverflow: subject.send,
+			Expiration: time.Minute,
+			Cap:        1,
+			Spool: buffer.SpoolConfig{
+				Directory:      dir,
+				ReplayInterval: 5 * time.Millisecond,
+			},
+		})
+		subject.buffer.Write(1)
+		time.Sleep(30 * time.Millisecond)
+		is.Equal(1, subject.acks.unacked(), "it should wait for the indexing")
+		is.Equal(1, held(dir, "*.held"), "it should keep the batch in the spool")
+		is.Equal(0, held(dir, "*.chunk"), "it should not replay the batch")
+		is.Equal(int32(1), atomic.LoadInt32(&sent), "it should send the batch once")
+		atomic.StoreInt32(&indexed, 1)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		is.Nil(subject.acks.pending.Wait(ctx), "it should confirm the batch")
+		is.Equal(0, held(dir, "*.held"), "it should remove the batch from the spool")
+	})
+	t.Run("when splunk does not confirm the indexing in time", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		dir, _ := ioutil.TempDir("", "spool")
+		defer os.RemoveAll(dir)
+		c := &http.Client{}
+		activateNonDefault(c)
+		url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+		bodies := make(chan string, 10)
+		var sent int32
+		httpmock.RegisterResponder("POST", url+"/event", func(request *http.Request) (response *http.Response, err error) {
+			body, _ := ioutil.ReadAll(request.Body)
+			bodies <- string(body)
+			if atomic.AddInt32(&sent, 1) == 1 {
+				return httpmock.NewStringResponse(200, `{"text":"Success","code":0,"ackId":5}`), nil
+			}
+			return httpmock.NewStringResponse(200, `{"text":"Success","code":0}`), nil
+		})
+		httpmock.RegisterResponder("POST", url+"/ack", func(request *http.Request) (response *http.Response, err error) {
+			return httpmock.NewStringResponse(200, `{"acks":{"5":false}}`), nil
+		})
+		subject := &Writer{
+			address:      url + "/event",
+			client:       c,
+			marshaller:   json.New(),
+			channel:      "channel",
+			errorHandler: func(ErrorEvent) {},
+			acks: newAcknowledger(Ack{
+				Interval: 5 * time.Millisecond,
+				Timeout:  20 * time.Millisecond,
+			}),
+		}
+		defer subject.acks.stop()
+		subject.buffer = buffer.New(buffer.Config{
+			OnOverflow: subject.send,
+			Expiration: time.Minute,
+			Cap:        1,
+			Spool: buffer.SpoolConfig{
+				Directory:      dir,
+				ReplayInterval: 5 * time.Millisecond,
+			},
+		})
+		subject.buffer.Write(1)
+		is.Equal("1", <-bodies, "it should send the batch")
+		select {
+		case body := <-bodies:
+			is.Equal("1", body, "it should replay the batch from the spool")
+		case <-time.After(time.Second):
+			is.Fail("it should replay the batch from the spool")
+		}
+		is.Equal(int64(1), subject.buffer.Stats().Spooled, "it should put the batch back in the spool")
+	})
+}
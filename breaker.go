@@ -0,0 +1,140 @@
+package splunk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+)
+
+const DefaultCoolDown = 30 * time.Second
+
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+type CircuitBreaker struct {
+	Threshold    int
+	CoolDown     time.Duration
+	DropWhenOpen bool
+}
+
+type CircuitOpenError struct {
+	Drop    bool
+	RetryIn time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open, next attempt in %v", e.RetryIn)
+}
+
+func (e *CircuitOpenError) Retryable() bool {
+	return !e.Drop
+}
+
+func (e *CircuitOpenError) RetryAfter() time.Duration {
+	return e.RetryIn
+}
+
+func (e *CircuitOpenError) Reason() string {
+	return "circuit_open"
+}
+
+type circuit struct {
+	sync.Mutex
+	CircuitBreaker
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuit(config CircuitBreaker) *circuit {
+	if config.CoolDown <= 0 {
+		config.CoolDown = DefaultCoolDown
+	}
+	return &circuit{
+		CircuitBreaker: config,
+		state:          CircuitClosed,
+	}
+}
+
+func (c *circuit) allow() error {
+	c.Lock()
+	defer c.Unlock()
+	switch c.state {
+	case CircuitOpen:
+		elapsed := time.Now().Sub(c.openedAt)
+		if elapsed < c.CoolDown {
+			return &CircuitOpenError{Drop: c.DropWhenOpen, RetryIn: c.CoolDown - elapsed}
+		}
+		c.state = CircuitHalfOpen
+		c.probing = true
+		return nil
+	case CircuitHalfOpen:
+		if c.probing {
+			return &CircuitOpenError{Drop: c.DropWhenOpen, RetryIn: c.CoolDown}
+		}
+		c.probing = true
+	}
+	return nil
+}
+
+func (c *circuit) record(err error) {
+	c.Lock()
+	defer c.Unlock()
+	failed := err != nil
+	if r, ok := err.(buffer.Retryable); ok && !r.Retryable() {
+		failed = false
+	}
+	if c.state == CircuitHalfOpen {
+		c.probing = false
+		if failed {
+			c.open()
+		} else {
+			c.state = CircuitClosed
+			c.failures = 0
+		}
+		return
+	}
+	if !failed {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.failures >= c.Threshold {
+		c.open()
+	}
+}
+
+func (c *circuit) open() {
+	c.state = CircuitOpen
+	c.openedAt = time.Now()
+	c.failures = 0
+}
+
+func (c *circuit) current() CircuitState {
+	if c == nil {
+		return ""
+	}
+	c.Lock()
+	defer c.Unlock()
+	return c.state
+}
+
+func (sw *Writer) deliver(events []interface{}) error {
+	if sw.circuit == nil {
+		return sw.send(events)
+	}
+	if err := sw.circuit.allow(); err != nil {
+		return err
+	}
+	err := sw.send(events)
+	sw.circuit.record(err)
+	return err
+}
@@ -0,0 +1,85 @@
+package splunk
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/icrowley/fake"
+	"github.com/jarcoal/httpmock"
+	"github.com/mundipagg/tracer-splunk-writer/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuit(t *testing.T) {
+	t.Parallel()
+	t.Run("when the failures reach the threshold", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := newCircuit(CircuitBreaker{Threshold: 2, CoolDown: time.Minute})
+		is.Nil(subject.allow(), "it should allow requests while closed")
+		subject.record(errors.New("refused"))
+		subject.record(nil)
+		subject.record(errors.New("refused"))
+		is.Equal(CircuitClosed, subject.current(), "it should count only consecutive failures")
+		subject.record(errors.New("refused"))
+		is.Equal(CircuitOpen, subject.current(), "it should open the circuit")
+		err := subject.allow()
+		is.IsType(&CircuitOpenError{}, err, "it should reject requests while open")
+		is.True(err.(*CircuitOpenError).Retryable(), "it should be retryable")
+		is.InDelta(time.Minute, err.(*CircuitOpenError).RetryAfter(), float64(time.Second), "it should retry after the cool-down")
+	})
+	t.Run("when the failure is not retryable", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := newCircuit(CircuitBreaker{Threshold: 1})
+		subject.record(&HECError{Status: 403, Category: NonRetryable})
+		is.Equal(CircuitClosed, subject.current(), "it should not open the circuit")
+	})
+	t.Run("when the cool-down is over", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := newCircuit(CircuitBreaker{Threshold: 1, CoolDown: time.Minute, DropWhenOpen: true})
+		subject.record(errors.New("refused"))
+		subject.openedAt = time.Now().Add(-time.Minute)
+		is.Nil(subject.allow(), "it should allow a probe")
+		is.Equal(CircuitHalfOpen, subject.current(), "it should be half-open")
+		err := subject.allow()
+		is.IsType(&CircuitOpenError{}, err, "it should allow a single probe")
+		is.False(err.(*CircuitOpenError).Retryable(), "it should drop the batch")
+		subject.record(errors.New("refused"))
+		is.Equal(CircuitOpen, subject.current(), "it should open again when the probe fails")
+		subject.openedAt = time.Now().Add(-time.Minute)
+		is.Nil(subject.allow(), "it should allow another probe")
+		subject.record(nil)
+		is.Equal(CircuitClosed, subject.current(), "it should close when the probe succeeds")
+		is.Nil(subject.allow(), "it should allow requests")
+	})
+}
+
+func TestWriter_Deliver(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	c := &http.Client{}
+	activateNonDefault(c)
+	url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+	requests := 0
+	httpmock.RegisterResponder("POST", url, func(request *http.Request) (response *http.Response, err error) {
+		requests++
+		return httpmock.NewStringResponse(503, `{"text":"Server is busy","code":9}`), nil
+	})
+	subject := &Writer{
+		address:    url,
+		client:     c,
+		marshaller: json.New(),
+		circuit:    newCircuit(CircuitBreaker{Threshold: 2}),
+		errorHandler: func(ErrorEvent) {
+		},
+	}
+	for i := 0; i < 4; i++ {
+		is.NotNil(subject.deliver([]interface{}{i}), "it should return an error")
+	}
+	is.Equal(2, requests, "it should stop sending requests once open")
+	is.Equal(CircuitOpen, subject.Stats().Circuit, "it should return the state of the circuit")
+}
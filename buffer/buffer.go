@@ -1,77 +1,252 @@
 package buffer
 
 import (
-	"fmt"
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/mundipagg/tracer-splunk-writer/pending"
 )
 
 const (
 	DefaultCapacity   = 100
 	DefaultOnWait     = 100
-	DefaultExpiration = 60000
-	DefaultBackoff    = 10000
+	DefaultExpiration = 60 * time.Second
+	DefaultBackoff    = 10 * time.Second
+	DefaultWorkers    = 4
+	DefaultMaxRetries = 100
 )
 
 type Buffer interface {
 	Write(item interface{})
+	Flusher
+	Closer
+	Reporter
+	Configurer
+}
+
+type Flusher interface {
+	Flush(ctx context.Context) (int, error)
+}
+
+type Closer interface {
+	Close(ctx context.Context) (int, error)
+}
+
+type Reporter interface {
+	Stats() Stats
+}
+
+type Configurer interface {
+	Configure(c Config)
+}
+
+type Stats struct {
+	Pending   int
+	Dropped   int64
+	DroppedBy map[string]int64
+	Retries   int64
+	RetriedBy map[string]int64
+	Spooled   int64
 }
 
 type buffer struct {
 	sync.Locker
-	cap        int
-	size       int
-	expiration time.Duration
-	chunks     chan entry
-	items      []interface{}
-	backoff    time.Duration
+	cap       int
+	size      int
+	bytes     int
+	config    atomic.Value
+	chunks    chan entry
+	items     []interface{}
+	pending   pending.Counter
+	dropped   int64
+	droppedBy counters
+	retries   int64
+	retriedBy counters
+	spooled   int64
+	closed    bool
+	done      chan struct{}
+	spool     *spool
+	replay    chan struct{}
+	onError   func(ErrorEvent)
 }
 
 func (b *buffer) Write(item interface{}) {
+	c := b.settings()
+	size, ok := b.measure(c, item)
+	if !ok {
+		return
+	}
+	item = size.item
 	b.Lock()
-	defer b.Unlock()
+	if b.closed {
+		b.Unlock()
+		b.discard(DroppedByClosed, 1)
+		return
+	}
+	var full, events []interface{}
+	if c.MaxBytes > 0 && b.size > 0 && b.bytes+size.bytes > c.MaxBytes {
+		full = b.take()
+	}
 	b.items[b.size] = item
 	b.size++
-	if b.size >= b.cap {
-		b.clear()
+	b.bytes += size.bytes
+	if b.size >= b.cap || (c.MaxBytes > 0 && b.bytes >= c.MaxBytes) {
+		events = b.take()
 	}
+	b.Unlock()
+	b.push(context.Background(), full)
+	b.push(context.Background(), events)
 }
 
-func (b *buffer) clear() {
-	if b.size > 0 {
-		events := b.items[:b.size]
-		b.size = 0
-		b.items = make([]interface{}, b.cap)
-		go func() {
-			b.chunks <- entry{
-				items:   events,
-				retries: cap(b.chunks),
-			}
-		}()
+func (b *buffer) Flush(ctx context.Context) (int, error) {
+	before := atomic.LoadInt64(&b.dropped)
+	b.Lock()
+	events := b.take()
+	b.Unlock()
+	b.push(ctx, events)
+	err := b.pending.Wait(ctx)
+	dropped := int(atomic.LoadInt64(&b.dropped) - before)
+	if err != nil {
+		dropped += b.pending.Len()
+	}
+	return dropped, err
+}
+
+func (b *buffer) Close(ctx context.Context) (int, error) {
+	b.Lock()
+	if b.closed {
+		b.Unlock()
+		return 0, nil
 	}
+	b.closed = true
+	b.Unlock()
+	dropped, err := b.Flush(ctx)
+	close(b.done)
+	return dropped, err
+}
+
+func (b *buffer) Stats() Stats {
+	return Stats{
+		Pending:   b.pending.Len(),
+		Dropped:   atomic.LoadInt64(&b.dropped),
+		DroppedBy: b.droppedBy.snapshot(),
+		Retries:   atomic.LoadInt64(&b.retries),
+		RetriedBy: b.retriedBy.snapshot(),
+		Spooled:   atomic.LoadInt64(&b.spooled),
+	}
+}
+
+func (b *buffer) Configure(c Config) {
+	b.Lock()
+	c = defaults(merge(c, b.settings()))
+	b.config.Store(c)
+	var events []interface{}
+	if c.Cap != b.cap {
+		b.cap = c.Cap
+		if b.size >= b.cap {
+			events = b.take()
+		} else {
+			items := make([]interface{}, b.cap)
+			copy(items, b.items[:b.size])
+			b.items = items
+		}
+	}
+	if c.MaxBytes > 0 && b.bytes >= c.MaxBytes {
+		events = append(events, b.take()...)
+	}
+	b.Unlock()
+	b.push(context.Background(), events)
+}
+
+func (b *buffer) settings() Config {
+	c, _ := b.config.Load().(Config)
+	return c
+}
+
+func (b *buffer) take() []interface{} {
+	if b.size == 0 {
+		return nil
+	}
+	events := b.items[:b.size]
+	b.size = 0
+	b.bytes = 0
+	b.items = make([]interface{}, b.cap)
+	b.pending.Add(len(events))
+	return events
+}
+
+func (b *buffer) push(ctx context.Context, events []interface{}) {
+	if len(events) == 0 {
+		return
+	}
+	chunk := entry{
+		items:   events,
+		retries: b.settings().MaxRetries,
+	}
+	select {
+	case b.chunks <- chunk:
+	case <-ctx.Done():
+		b.drop(DroppedByDeadline, chunk)
+	case <-b.done:
+		b.drop(DroppedByClosed, chunk)
+	}
+}
+
+func (b *buffer) report(e ErrorEvent) {
+	if b.onError == nil {
+		DefaultErrorHandler(e)
+		return
+	}
+	b.onError(e)
+}
+
+func (b *buffer) drop(reason string, events entry) {
+	b.discard(reason, len(events.items))
+	b.pending.Done(len(events.items))
+}
+
+func (b *buffer) discard(reason string, n int) {
+	atomic.AddInt64(&b.dropped, int64(n))
+	b.droppedBy.add(reason, int64(n))
 }
 
 func (b *buffer) watcher() {
 	defer func() {
 		err := recover()
 		if err != nil {
-			fmt.Printf("%v\n", err)
+			b.report(ErrorEvent{Stage: StageBuffer, Err: panicked(err)})
 		}
 	}()
 	for {
-		time.Sleep(b.expiration)
-		b.Lock()
-		b.clear()
-		b.Unlock()
+		select {
+		case <-time.After(b.settings().Expiration):
+			b.Lock()
+			events := b.take()
+			b.Unlock()
+			b.push(context.Background(), events)
+		case <-b.done:
+			return
+		}
 	}
 }
 
 type Config struct {
-	Cap        int
-	OnWait     int
-	Expiration time.Duration
-	BackOff    time.Duration
-	OnOverflow func([]interface{}) error
+	Cap          int
+	OnWait       int
+	Workers      int
+	MaxBytes     int
+	Size         func(interface{}) int
+	Truncate     func(interface{}, int) (interface{}, bool)
+	Expiration   time.Duration
+	BackOff      time.Duration
+	RetryPolicy  RetryPolicy
+	MaxRetries   int
+	OnFailure    func(Attempt)
+	OnOverflow   func([]interface{}) error
+	ErrorHandler func(ErrorEvent)
+	Spool        SpoolConfig
 }
 
 type entry struct {
@@ -79,7 +254,7 @@ type entry struct {
 	retries int
 }
 
-func New(c Config) Buffer {
+func defaults(c Config) Config {
 	if c.Cap == 0 {
 		c.Cap = DefaultCapacity
 	}
@@ -92,40 +267,187 @@ func New(c Config) Buffer {
 	if c.OnWait == 0 {
 		c.OnWait = DefaultOnWait
 	}
+	if c.Workers == 0 {
+		c.Workers = DefaultWorkers
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = DefaultMaxRetries
+	}
+	if c.ErrorHandler == nil {
+		c.ErrorHandler = DefaultErrorHandler
+	}
+	if c.RetryPolicy == nil {
+		c.RetryPolicy = Constant{Delay: c.BackOff}
+	}
+	return c
+}
 
+func merge(c Config, current Config) Config {
+	c.Workers = current.Workers
+	c.OnWait = current.OnWait
+	c.Spool = current.Spool
+	if c.Cap == 0 {
+		c.Cap = current.Cap
+	}
+	if c.MaxBytes == 0 {
+		c.MaxBytes = current.MaxBytes
+	}
+	if c.Expiration == 0 {
+		c.Expiration = current.Expiration
+	}
+	if c.BackOff == 0 {
+		c.BackOff = current.BackOff
+	}
+	if c.RetryPolicy == nil && (c.BackOff == current.BackOff || !derived(current)) {
+		c.RetryPolicy = current.RetryPolicy
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = current.MaxRetries
+	}
+	if c.Size == nil {
+		c.Size = current.Size
+	}
+	if c.Truncate == nil {
+		c.Truncate = current.Truncate
+	}
+	if c.OnFailure == nil {
+		c.OnFailure = current.OnFailure
+	}
+	if c.OnOverflow == nil {
+		c.OnOverflow = current.OnOverflow
+	}
+	if c.ErrorHandler == nil {
+		c.ErrorHandler = current.ErrorHandler
+	}
+	return c
+}
+
+func derived(c Config) bool {
+	policy, ok := c.RetryPolicy.(Constant)
+	return ok && policy.Delay == c.BackOff
+}
+
+func New(c Config) Buffer {
+	c = defaults(c)
 	b := &buffer{
-		Locker:     &sync.Mutex{},
-		size:       0,
-		cap:        c.Cap,
-		expiration: c.Expiration,
-		chunks:     make(chan entry, c.OnWait),
-		items:      make([]interface{}, c.Cap),
-		backoff:    c.BackOff,
+		Locker:  &sync.Mutex{},
+		size:    0,
+		cap:     c.Cap,
+		chunks:  make(chan entry, c.OnWait),
+		items:   make([]interface{}, c.Cap),
+		done:    make(chan struct{}),
+		replay:  make(chan struct{}, 1),
+		onError: c.ErrorHandler,
+	}
+	b.config.Store(c)
+	if len(c.Spool.Directory) > 0 {
+		s, err := newSpool(c.Spool)
+		if err != nil {
+			b.report(ErrorEvent{Stage: StageSpool, Err: err})
+		} else {
+			b.spool = s
+			go b.replayer(c)
+			b.kickReplay()
+		}
 	}
 	go b.watcher()
-	go b.consumer(c)
+	for i := 0; i < c.Workers; i++ {
+		go b.consumer()
+	}
 	return b
 }
 
-func (b *buffer) consumer(c Config) {
+func (b *buffer) consumer() {
+	for {
+		select {
+		case events := <-b.chunks:
+			b.send(b.settings(), events)
+		case <-b.done:
+			return
+		}
+	}
+}
+
+func (b *buffer) send(c Config, events entry) {
 	defer func() {
 		err := recover()
 		if err != nil {
-			fmt.Printf("%v\n", err)
+			b.drop(DroppedByPanic, events)
+			b.report(ErrorEvent{
+				Stage:     StageBuffer,
+				BatchSize: len(events.items),
+				Err:       panicked(err),
+				Dropped:   len(events.items),
+			})
 		}
 	}()
-	for events := range b.chunks {
-		go func(events entry) {
-			err := c.OnOverflow(events.items)
-			if err != nil {
-				go func(events entry) {
-					events.retries--
-					if events.retries >= 0 {
-						time.Sleep(b.backoff)
-						b.chunks <- events
-					}
-				}(events)
+	started := time.Now()
+	for attempt := 1; ; attempt++ {
+		err := c.OnOverflow(events.items)
+		if err == nil {
+			b.pending.Done(len(events.items))
+			b.kickReplay()
+			return
+		}
+		if items := remaining(err, events.items); len(items) < len(events.items) {
+			b.pending.Done(len(events.items) - len(items))
+			events.items = items
+		}
+		if b.spool != nil && retryable(err) {
+			err := b.spool.store(events.items)
+			if err == nil {
+				atomic.AddInt64(&b.spooled, int64(len(events.items)))
+				b.pending.Done(len(events.items))
+				return
 			}
-		}(events)
+			b.report(ErrorEvent{
+				Stage:     StageSpool,
+				BatchSize: len(events.items),
+				Err:       err,
+			})
+		}
+		elapsed := time.Since(started)
+		delay, retry := c.RetryPolicy.Next(attempt, elapsed)
+		if events.retries == 0 || !retryable(err) {
+			retry = false
+		}
+		if after := retryAfter(err); after > delay {
+			delay = after
+		}
+		if c.OnFailure != nil {
+			c.OnFailure(Attempt{
+				Number:  attempt,
+				Items:   len(events.items),
+				Err:     err,
+				Elapsed: elapsed,
+				Delay:   delay,
+				Retry:   retry,
+			})
+		}
+		if !retry {
+			if retryable(err) {
+				b.drop(DroppedByRetries, events)
+			} else {
+				b.drop(DroppedByRejected, events)
+			}
+			b.report(ErrorEvent{
+				Stage:     StageSend,
+				BatchSize: len(events.items),
+				Err:       err,
+				Dropped:   len(events.items),
+			})
+			return
+		}
+		if events.retries > 0 {
+			events.retries--
+		}
+		atomic.AddInt64(&b.retries, 1)
+		b.retriedBy.add(reason(err), 1)
+		select {
+		case <-time.After(delay):
+		case <-b.done:
+			b.drop(DroppedByClosed, events)
+			return
+		}
 	}
 }
@@ -1,6 +1,7 @@
 package buffer
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
@@ -35,6 +36,7 @@ func TestBuffer_Write(t *testing.T) {
 			items:  make([]interface{}, 1),
 			chunks: make(chan entry, 10),
 		}
+		subject.config.Store(Config{MaxRetries: 10})
 		subject.Write("something")
 		is.Equal(0, subject.size, "it should remain zero")
 		is.Equal([]interface{}{nil}, subject.items, "it should clean the buffer's inner slice")
@@ -177,3 +179,211 @@ func TestNew(t *testing.T) {
 		})
 	})
 }
+
+func TestBuffer_Flush(t *testing.T) {
+	t.Parallel()
+	t.Run("when the consumer returns no error", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		called := make(chan []interface{}, 1)
+		subject := New(Config{
+			OnOverflow: func(items []interface{}) error {
+				called <- items
+				return nil
+			},
+			BackOff:    10 * time.Millisecond,
+			Expiration: time.Minute,
+			Cap:        10,
+			OnWait:     10,
+		})
+		subject.Write(1)
+		subject.Write(2)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		dropped, err := subject.Flush(ctx)
+		is.Nil(err, "it should return no error")
+		is.Equal(0, dropped, "it should drop nothing")
+		is.Equal([]interface{}{1, 2}, <-called, "it should send the partial batch")
+	})
+	t.Run("when the consumer keeps failing until the deadline", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := New(Config{
+			OnOverflow: func(items []interface{}) error {
+				return errors.New("failed")
+			},
+			BackOff:    time.Minute,
+			Expiration: time.Minute,
+			Cap:        10,
+			OnWait:     10,
+		})
+		subject.Write(1)
+		subject.Write(2)
+		subject.Write(3)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		dropped, err := subject.Flush(ctx)
+		is.Equal(context.DeadlineExceeded, err, "it should return the context error")
+		is.Equal(3, dropped, "it should report the events not delivered")
+	})
+	t.Run("when the consumer sends part of the batch", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		called := make(chan []interface{}, 2)
+		subject := New(Config{
+			OnOverflow: func(items []interface{}) error {
+				called <- items
+				if len(items) == 3 {
+					return &PartialError{Err: errors.New("failed"), Remaining: items[2:]}
+				}
+				return nil
+			},
+			BackOff:    time.Millisecond,
+			Expiration: time.Minute,
+			Cap:        10,
+		})
+		subject.Write(1)
+		subject.Write(2)
+		subject.Write(3)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		dropped, err := subject.Flush(ctx)
+		is.Nil(err, "it should return no error")
+		is.Equal(0, dropped, "it should drop nothing")
+		is.Equal([]interface{}{1, 2, 3}, <-called, "it should send the batch")
+		is.Equal([]interface{}{3}, <-called, "it should retry only the remaining items")
+		is.Equal(0, subject.Stats().Pending, "it should not count the sent items as pending")
+	})
+	t.Run("when the retries are exhausted", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := New(Config{
+			OnOverflow: func(items []interface{}) error {
+				return errors.New("failed")
+			},
+			BackOff:    time.Millisecond,
+			Expiration: time.Minute,
+			Cap:        10,
+			MaxRetries: 1,
+		})
+		subject.Write(1)
+		subject.Write(2)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		dropped, err := subject.Flush(ctx)
+		is.Nil(err, "it should return no error")
+		is.Equal(2, dropped, "it should report the events dropped")
+	})
+}
+
+func TestBuffer_Close(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	called := make(chan []interface{}, 2)
+	subject := New(Config{
+		OnOverflow: func(items []interface{}) error {
+			called <- items
+			return nil
+		},
+		BackOff:    10 * time.Millisecond,
+		Expiration: time.Minute,
+		Cap:        10,
+		OnWait:     10,
+	})
+	subject.Write(1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	dropped, err := subject.Close(ctx)
+	is.Nil(err, "it should return no error")
+	is.Equal(0, dropped, "it should drop nothing")
+	is.Equal([]interface{}{1}, <-called, "it should send the partial batch")
+	subject.Write(2)
+	is.Equal(int64(1), subject.(*buffer).dropped, "it should drop the events written after close")
+}
+
+func TestBuffer_Configure(t *testing.T) {
+	t.Parallel()
+	t.Run("when the capacity is reduced below the buffered items", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		overflow := func([]interface{}) error { return nil }
+		subject := &buffer{
+			Locker: &sync.Mutex{},
+			cap:    10,
+			items:  make([]interface{}, 10),
+			chunks: make(chan entry, 10),
+		}
+		subject.config.Store(Config{Workers: 2, OnWait: 10, OnOverflow: overflow})
+		subject.Write("a")
+		subject.Write("b")
+		subject.Configure(Config{Cap: 2, MaxRetries: 3, Workers: 8})
+		is.Len(subject.chunks, 1, "it should send the buffered items")
+		is.Equal(entry{items: []interface{}{"a", "b"}, retries: 3}, <-subject.chunks, "it should use the new configuration")
+		actual := subject.settings()
+		is.Equal(2, actual.Workers, "it should keep the number of workers")
+		is.Equal(DefaultExpiration, actual.Expiration, "it should apply the defaults")
+		is.NotNil(actual.OnOverflow, "it should keep the consumer")
+	})
+	t.Run("when only some fields are given", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		policy := Exponential{Initial: time.Millisecond}
+		var failures int
+		subject := &buffer{
+			Locker: &sync.Mutex{},
+			cap:    10,
+			items:  make([]interface{}, 10),
+			chunks: make(chan entry, 10),
+		}
+		subject.config.Store(defaults(Config{
+			Cap:         10,
+			MaxBytes:    1024,
+			MaxRetries:  1,
+			Expiration:  time.Minute,
+			RetryPolicy: policy,
+			OnFailure: func(Attempt) {
+				failures++
+			},
+		}))
+		subject.Configure(Config{Cap: 1})
+		actual := subject.settings()
+		is.Equal(1, actual.Cap, "it should apply the given fields")
+		is.Equal(1024, actual.MaxBytes, "it should keep the size limit")
+		is.Equal(1, actual.MaxRetries, "it should keep the retries")
+		is.Equal(time.Minute, actual.Expiration, "it should keep the expiration")
+		is.Equal(policy, actual.RetryPolicy, "it should keep the retry policy")
+		actual.OnFailure(Attempt{})
+		is.Equal(1, failures, "it should keep the failure callback")
+		subject.Configure(Config{BackOff: time.Second})
+		is.Equal(policy, subject.settings().RetryPolicy, "it should keep the retry policy when the back-off changes")
+	})
+	t.Run("when the back-off of the default policy changes", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &buffer{
+			Locker: &sync.Mutex{},
+			cap:    10,
+			items:  make([]interface{}, 10),
+			chunks: make(chan entry, 10),
+		}
+		subject.config.Store(defaults(Config{BackOff: time.Millisecond}))
+		subject.Configure(Config{BackOff: time.Second})
+		is.Equal(Constant{Delay: time.Second}, subject.settings().RetryPolicy, "it should rebuild the default policy")
+	})
+	t.Run("when the capacity is increased", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &buffer{
+			Locker: &sync.Mutex{},
+			cap:    2,
+			items:  make([]interface{}, 2),
+			chunks: make(chan entry, 10),
+		}
+		subject.Write("a")
+		subject.Configure(Config{Cap: 3})
+		subject.Write("b")
+		is.Len(subject.chunks, 0, "it should keep the items buffered")
+		subject.Write("c")
+		is.Equal([]interface{}{"a", "b", "c"}, (<-subject.chunks).items, "it should flush with the new capacity")
+	})
+}
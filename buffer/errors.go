@@ -0,0 +1,135 @@
+package buffer
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+type Retryable interface {
+	Retryable() bool
+}
+
+type Delayed interface {
+	RetryAfter() time.Duration
+}
+
+type Reasoned interface {
+	Reason() string
+}
+
+// PartialError is returned by OnOverflow when only part of the items were handled,
+// the buffer then retries only the Remaining ones.
+type PartialError struct {
+	Err       error
+	Remaining []interface{}
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PartialError) Retryable() bool {
+	return retryable(e.Err)
+}
+
+func (e *PartialError) RetryAfter() time.Duration {
+	return retryAfter(e.Err)
+}
+
+func (e *PartialError) Reason() string {
+	return reason(e.Err)
+}
+
+func retryable(err error) bool {
+	if r, ok := err.(Retryable); ok {
+		return r.Retryable()
+	}
+	return true
+}
+
+func retryAfter(err error) time.Duration {
+	if d, ok := err.(Delayed); ok {
+		return d.RetryAfter()
+	}
+	return 0
+}
+
+func reason(err error) string {
+	if r, ok := err.(Reasoned); ok {
+		return r.Reason()
+	}
+	return DefaultReason
+}
+
+func remaining(err error, items []interface{}) []interface{} {
+	if p, ok := err.(*PartialError); ok {
+		return p.Remaining
+	}
+	return items
+}
+
+type Stage string
+
+const (
+	StageProcess   Stage = "process"
+	StageEncode    Stage = "encode"
+	StageSend      Stage = "send"
+	StageSpool     Stage = "spool"
+	StageReplay    Stage = "replay"
+	StageBuffer    Stage = "buffer"
+	StageOversized Stage = "oversized"
+	StageAck       Stage = "ack"
+	StageConfig    Stage = "config"
+)
+
+var stageMessages = map[Stage]string{
+	StageProcess:   "COULD NOT PROCESS LOG",
+	StageEncode:    "COULD NOT ENCODE LOG",
+	StageSend:      "COULD NOT SEND LOG TO SPLUNK",
+	StageSpool:     "COULD NOT SPOOL CHUNK",
+	StageReplay:    "COULD NOT REPLAY SPOOLED CHUNK",
+	StageBuffer:    "BUFFER FAILED",
+	StageOversized: "COULD NOT BUFFER LOG",
+	StageAck:       "COULD NOT CONFIRM LOG INDEXING",
+	StageConfig:    "INVALID CONFIGURATION",
+}
+
+type ErrorEvent struct {
+	Stage     Stage
+	BatchSize int
+	Status    int
+	Err       error
+	Dropped   int
+}
+
+func (e ErrorEvent) Error() string {
+	message, ok := stageMessages[e.Stage]
+	if !ok {
+		message = "SPLUNK WRITER FAILED"
+	}
+	message = fmt.Sprintf("%v BECAUSE %v", message, e.Err)
+	if e.Dropped > 0 {
+		message = fmt.Sprintf("%v, %v EVENTS WERE DROPPED", message, e.Dropped)
+	}
+	return message
+}
+
+func (e ErrorEvent) Unwrap() error {
+	return e.Err
+}
+
+func DefaultErrorHandler(e ErrorEvent) {
+	fmt.Fprintln(os.Stderr, e.Error())
+}
+
+func panicked(value interface{}) error {
+	if err, ok := value.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", value)
+}
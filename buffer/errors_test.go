@@ -0,0 +1,24 @@
+package buffer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorEvent_Error(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	failure := errors.New("failed")
+	cases := map[string]ErrorEvent{
+		"COULD NOT SEND LOG TO SPLUNK BECAUSE failed":                        {Stage: StageSend, Err: failure},
+		"COULD NOT SEND LOG TO SPLUNK BECAUSE failed, 3 EVENTS WERE DROPPED": {Stage: StageSend, Err: failure, Dropped: 3},
+		"COULD NOT ENCODE LOG BECAUSE failed":                                {Stage: StageEncode, Err: failure},
+		"SPLUNK WRITER FAILED BECAUSE failed":                                {Stage: "unknown", Err: failure},
+	}
+	for expected, input := range cases {
+		is.Equal(expected, input.Error(), "it should return the expected message")
+		is.Equal(failure, input.Unwrap(), "it should unwrap the underlying error")
+	}
+}
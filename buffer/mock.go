@@ -1,6 +1,10 @@
 package buffer
 
-import "github.com/stretchr/testify/mock"
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
 
 type Mock struct {
 	mock.Mock
@@ -9,3 +13,22 @@ type Mock struct {
 func (m *Mock) Write(item interface{}) {
 	m.Called(item)
 }
+
+func (m *Mock) Flush(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *Mock) Close(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *Mock) Stats() Stats {
+	args := m.Called()
+	return args.Get(0).(Stats)
+}
+
+func (m *Mock) Configure(c Config) {
+	m.Called(c)
+}
@@ -0,0 +1,41 @@
+package buffer
+
+import (
+	"sync"
+)
+
+const DefaultReason = "error"
+
+const (
+	DroppedByClosed    = "closed"
+	DroppedByDeadline  = "deadline"
+	DroppedByOversized = "oversized"
+	DroppedByRejected  = "rejected"
+	DroppedByRetries   = "retries_exhausted"
+	DroppedByPanic     = "panic"
+	DroppedByExpired   = "expired"
+)
+
+type counters struct {
+	sync.Mutex
+	values map[string]int64
+}
+
+func (c *counters) add(reason string, n int64) {
+	c.Lock()
+	defer c.Unlock()
+	if c.values == nil {
+		c.values = map[string]int64{}
+	}
+	c.values[reason] += n
+}
+
+func (c *counters) snapshot() map[string]int64 {
+	c.Lock()
+	defer c.Unlock()
+	values := make(map[string]int64, len(c.values))
+	for reason, n := range c.values {
+		values[reason] = n
+	}
+	return values
+}
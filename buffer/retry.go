@@ -0,0 +1,64 @@
+package buffer
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+type RetryPolicy interface {
+	Next(attempt int, elapsed time.Duration) (time.Duration, bool)
+}
+
+type Attempt struct {
+	Number  int
+	Items   int
+	Err     error
+	Elapsed time.Duration
+	Delay   time.Duration
+	Retry   bool
+}
+
+type Constant struct {
+	Delay time.Duration
+}
+
+func (c Constant) Next(attempt int, elapsed time.Duration) (time.Duration, bool) {
+	return c.Delay, true
+}
+
+type Exponential struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+func (e Exponential) Next(attempt int, elapsed time.Duration) (time.Duration, bool) {
+	multiplier := e.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+	delay := float64(e.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if e.Max > 0 && delay > float64(e.Max) {
+		delay = float64(e.Max)
+	}
+	if e.Jitter > 0 {
+		jitter := math.Min(e.Jitter, 1)
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay), true
+}
+
+type MaxElapsed struct {
+	Policy     RetryPolicy
+	MaxElapsed time.Duration
+}
+
+func (m MaxElapsed) Next(attempt int, elapsed time.Duration) (time.Duration, bool) {
+	delay, retry := m.Policy.Next(attempt, elapsed)
+	if !retry || elapsed+delay > m.MaxElapsed {
+		return 0, false
+	}
+	return delay, true
+}
@@ -0,0 +1,166 @@
+package buffer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstant_Next(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	subject := Constant{Delay: time.Second}
+	delay, retry := subject.Next(10, time.Hour)
+	is.Equal(time.Second, delay, "it should return the same delay")
+	is.True(retry, "it should always retry")
+}
+
+func TestExponential_Next(t *testing.T) {
+	t.Parallel()
+	t.Run("when there is no jitter", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := Exponential{
+			Initial: 100 * time.Millisecond,
+			Max:     time.Second,
+		}
+		cases := map[int]time.Duration{
+			1: 100 * time.Millisecond,
+			2: 200 * time.Millisecond,
+			3: 400 * time.Millisecond,
+			4: 800 * time.Millisecond,
+			5: time.Second,
+			6: time.Second,
+		}
+		for attempt, expected := range cases {
+			delay, retry := subject.Next(attempt, 0)
+			is.Equal(expected, delay, "it should return the expected delay")
+			is.True(retry, "it should always retry")
+		}
+	})
+	t.Run("when there is jitter", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := Exponential{
+			Initial:    time.Second,
+			Multiplier: 3,
+			Jitter:     0.5,
+		}
+		for i := 0; i < 100; i++ {
+			delay, _ := subject.Next(2, 0)
+			is.True(delay > 1500*time.Millisecond && delay <= 3*time.Second, "it should return a delay within the jitter range")
+		}
+	})
+}
+
+func TestMaxElapsed_Next(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	subject := MaxElapsed{
+		Policy:     Constant{Delay: time.Second},
+		MaxElapsed: 10 * time.Second,
+	}
+	delay, retry := subject.Next(1, 5*time.Second)
+	is.Equal(time.Second, delay, "it should return the inner delay")
+	is.True(retry, "it should retry while the elapsed time is below the maximum")
+	_, retry = subject.Next(1, 9500*time.Millisecond)
+	is.False(retry, "it should not retry past the maximum elapsed time")
+}
+
+func TestBuffer_Retry(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	attempts := make(chan Attempt, 10)
+	failure := busy{}
+	subject := New(Config{
+		OnOverflow: func(items []interface{}) error {
+			return failure
+		},
+		RetryPolicy: Exponential{Initial: time.Millisecond},
+		MaxRetries:  2,
+		OnFailure: func(attempt Attempt) {
+			attempts <- attempt
+		},
+		Expiration: time.Minute,
+		Cap:        10,
+	})
+	subject.Write(1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	dropped, err := subject.Flush(ctx)
+	is.Nil(err, "it should return no error")
+	is.Equal(1, dropped, "it should drop the events after the retries")
+	close(attempts)
+	var actual []Attempt
+	for attempt := range attempts {
+		is.Equal(failure, attempt.Err, "it should report the error")
+		is.Equal(1, attempt.Items, "it should report the number of items")
+		attempt.Err = nil
+		attempt.Elapsed = 0
+		actual = append(actual, attempt)
+	}
+	expected := []Attempt{
+		{Number: 1, Items: 1, Delay: time.Millisecond, Retry: true},
+		{Number: 2, Items: 1, Delay: 2 * time.Millisecond, Retry: true},
+		{Number: 3, Items: 1, Delay: 4 * time.Millisecond, Retry: false},
+	}
+	is.Equal(expected, actual, "it should report every attempt")
+	stats := subject.Stats()
+	is.Equal(map[string]int64{"busy": 2}, stats.RetriedBy, "it should count the retries by reason")
+	is.Equal(map[string]int64{DroppedByRetries: 1}, stats.DroppedBy, "it should count the events dropped after the retries")
+}
+
+type busy struct{}
+
+func (busy) Error() string {
+	return "busy"
+}
+
+func (busy) Reason() string {
+	return "busy"
+}
+
+type permanent struct{}
+
+func (permanent) Error() string {
+	return "permanent"
+}
+
+func (permanent) Retryable() bool {
+	return false
+}
+
+func TestBuffer_NonRetryable(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	calls := make(chan []interface{}, 10)
+	reported := make(chan ErrorEvent, 10)
+	subject := New(Config{
+		OnOverflow: func(items []interface{}) error {
+			calls <- items
+			return permanent{}
+		},
+		ErrorHandler: func(e ErrorEvent) {
+			reported <- e
+		},
+		RetryPolicy: Constant{Delay: time.Millisecond},
+		Expiration:  time.Minute,
+		Cap:         10,
+	})
+	subject.Write(1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	dropped, err := subject.Flush(ctx)
+	is.Nil(err, "it should return no error")
+	is.Equal(1, dropped, "it should drop the events without retrying")
+	is.Len(calls, 1, "it should not retry")
+	is.Equal(ErrorEvent{
+		Stage:     StageSend,
+		BatchSize: 1,
+		Err:       permanent{},
+		Dropped:   1,
+	}, <-reported, "it should report the dropped events")
+	is.Equal(map[string]int64{DroppedByRejected: 1}, subject.Stats().DroppedBy, "it should count the rejected events")
+}
@@ -0,0 +1,36 @@
+package buffer
+
+import (
+	"fmt"
+)
+
+type measured struct {
+	item  interface{}
+	bytes int
+}
+
+func (b *buffer) measure(c Config, item interface{}) (measured, bool) {
+	if c.MaxBytes <= 0 || c.Size == nil {
+		return measured{item: item}, true
+	}
+	// one extra byte for the separator between events
+	size := c.Size(item) + 1
+	if size <= c.MaxBytes {
+		return measured{item: item, bytes: size}, true
+	}
+	if c.Truncate != nil {
+		if truncated, ok := c.Truncate(item, c.MaxBytes-1); ok {
+			if size := c.Size(truncated) + 1; size <= c.MaxBytes {
+				return measured{item: truncated, bytes: size}, true
+			}
+		}
+	}
+	b.discard(DroppedByOversized, 1)
+	b.report(ErrorEvent{
+		Stage:     StageOversized,
+		BatchSize: 1,
+		Err:       fmt.Errorf("event of %v bytes exceeds the maximum of %v bytes", size-1, c.MaxBytes-1),
+		Dropped:   1,
+	})
+	return measured{}, false
+}
@@ -0,0 +1,101 @@
+package buffer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer_WriteBytes(t *testing.T) {
+	t.Parallel()
+	length := func(item interface{}) int {
+		return len(item.(string))
+	}
+	t.Run("when the byte threshold is reached", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &buffer{
+			Locker: &sync.Mutex{},
+			cap:    10,
+			items:  make([]interface{}, 10),
+			chunks: make(chan entry, 10),
+		}
+		subject.config.Store(Config{MaxBytes: 12, Size: length})
+		subject.Write("aaaa")
+		subject.Write("bbbb")
+		is.Len(subject.chunks, 0, "it should not flush below the threshold")
+		subject.Write("cccc")
+		is.Len(subject.chunks, 1, "it should flush before exceeding the threshold")
+		is.Equal([]interface{}{"aaaa", "bbbb"}, (<-subject.chunks).items, "it should flush the previous items")
+		is.Equal(1, subject.size, "it should keep the new item")
+		is.Equal(5, subject.bytes, "it should track the size of the new item")
+		subject.Write("dddddd")
+		is.Len(subject.chunks, 1, "it should flush when the threshold is reached")
+		is.Equal([]interface{}{"cccc", "dddddd"}, (<-subject.chunks).items, "it should flush all items")
+		is.Equal(0, subject.bytes, "it should reset the size")
+	})
+	t.Run("when an item is too large", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		var reported []ErrorEvent
+		subject := &buffer{
+			Locker: &sync.Mutex{},
+			cap:    10,
+			items:  make([]interface{}, 10),
+			chunks: make(chan entry, 10),
+			onError: func(e ErrorEvent) {
+				reported = append(reported, e)
+			},
+		}
+		subject.config.Store(Config{MaxBytes: 5, Size: length})
+		subject.Write("too large")
+		is.Equal(0, subject.size, "it should not buffer the item")
+		is.Equal(int64(1), subject.dropped, "it should drop the item")
+		is.Equal(map[string]int64{DroppedByOversized: 1}, subject.droppedBy.snapshot(), "it should count the item as oversized")
+		is.Len(reported, 1, "it should report the item")
+		is.Equal(StageOversized, reported[0].Stage, "it should report the stage")
+		is.Equal(1, reported[0].Dropped, "it should report the dropped item")
+	})
+	t.Run("when an item is too large but can be truncated", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &buffer{
+			Locker: &sync.Mutex{},
+			cap:    10,
+			items:  make([]interface{}, 10),
+			chunks: make(chan entry, 10),
+		}
+		subject.config.Store(Config{
+			MaxBytes: 5,
+			Size:     length,
+			Truncate: func(item interface{}, max int) (interface{}, bool) {
+				return item.(string)[:max], true
+			},
+		})
+		subject.Write("too large")
+		is.Equal([]interface{}{"too "}, (<-subject.chunks).items, "it should buffer the truncated item")
+	})
+}
+
+func TestNew_MaxBytes(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	called := make(chan []interface{}, 2)
+	subject := New(Config{
+		OnOverflow: func(items []interface{}) error {
+			called <- items
+			return nil
+		},
+		Expiration: time.Minute,
+		MaxBytes:   4,
+		Size: func(item interface{}) int {
+			return 1
+		},
+	})
+	subject.Write(1)
+	subject.Write(2)
+	subject.Write(3)
+	is.Equal([]interface{}{1, 2}, <-called, "it should send the items that fit")
+}
@@ -0,0 +1,266 @@
+package buffer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	spoolExtension        = ".chunk"
+	heldExtension         = ".held"
+	DefaultReplayInterval = 30 * time.Second
+)
+
+type SpoolConfig struct {
+	Directory      string
+	MaxBytes       int64
+	MaxAge         time.Duration
+	ReplayInterval time.Duration
+	Marshal        func(interface{}) ([]byte, error)
+	Unmarshal      func([]byte) (interface{}, error)
+}
+
+// Spooler keeps items in the spool without replaying them, until they are
+// either released once delivered or requeued to be replayed.
+type Spooler interface {
+	Hold(items []interface{}) (string, error)
+	Release(name string)
+	Requeue(name string) error
+}
+
+type spool struct {
+	SpoolConfig
+	sync.Mutex
+	sequence uint64
+}
+
+func newSpool(c SpoolConfig) (*spool, error) {
+	if c.Marshal == nil {
+		c.Marshal = json.Marshal
+	}
+	if c.Unmarshal == nil {
+		c.Unmarshal = func(data []byte) (interface{}, error) {
+			return json.RawMessage(data), nil
+		}
+	}
+	if c.ReplayInterval == 0 {
+		c.ReplayInterval = DefaultReplayInterval
+	}
+	if err := os.MkdirAll(c.Directory, 0755); err != nil {
+		return nil, err
+	}
+	s := &spool{
+		SpoolConfig: c,
+	}
+	for _, file := range s.files(heldExtension) {
+		_ = s.requeue(file.Name())
+	}
+	return s, nil
+}
+
+func (s *spool) store(items []interface{}) error {
+	_, err := s.write(items, spoolExtension)
+	return err
+}
+
+func (s *spool) hold(items []interface{}) (string, error) {
+	return s.write(items, heldExtension)
+}
+
+func (s *spool) requeue(name string) error {
+	path := filepath.Join(s.Directory, name)
+	return os.Rename(path, strings.TrimSuffix(path, heldExtension)+spoolExtension)
+}
+
+func (s *spool) write(items []interface{}, extension string) (string, error) {
+	var body []byte
+	for _, item := range items {
+		data, err := s.Marshal(item)
+		if err != nil {
+			return "", err
+		}
+		var prefix [4]byte
+		binary.BigEndian.PutUint32(prefix[:], uint32(len(data)))
+		body = append(body, prefix[:]...)
+		body = append(body, data...)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if s.MaxBytes > 0 && s.size()+int64(len(body)) > s.MaxBytes {
+		return "", fmt.Errorf("spool is full (%v bytes)", s.MaxBytes)
+	}
+	name := fmt.Sprintf("%020d-%010d", time.Now().UnixNano(), atomic.AddUint64(&s.sequence, 1))
+	temp := filepath.Join(s.Directory, name+".tmp")
+	if err := ioutil.WriteFile(temp, body, 0644); err != nil {
+		_ = os.Remove(temp)
+		return "", err
+	}
+	return name + extension, os.Rename(temp, filepath.Join(s.Directory, name+extension))
+}
+
+func (s *spool) files(extension string) []os.FileInfo {
+	infos, err := ioutil.ReadDir(s.Directory)
+	if err != nil {
+		return nil
+	}
+	var files []os.FileInfo
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasSuffix(info.Name(), extension) {
+			files = append(files, info)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+	return files
+}
+
+func (s *spool) size() int64 {
+	var total int64
+	for _, file := range append(s.files(spoolExtension), s.files(heldExtension)...) {
+		total += file.Size()
+	}
+	return total
+}
+
+func (s *spool) expired(file os.FileInfo) bool {
+	return s.MaxAge > 0 && time.Since(file.ModTime()) > s.MaxAge
+}
+
+func (s *spool) load(name string) ([]interface{}, error) {
+	f, err := os.Open(filepath.Join(s.Directory, name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	var items []interface{}
+	for {
+		var prefix [4]byte
+		if _, err := io.ReadFull(reader, prefix[:]); err == io.EOF {
+			return items, nil
+		} else if err != nil {
+			return nil, err
+		}
+		data := make([]byte, binary.BigEndian.Uint32(prefix[:]))
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		item, err := s.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+func (s *spool) count(name string) int {
+	items, _ := s.load(name)
+	return len(items)
+}
+
+func (s *spool) remove(name string) {
+	_ = os.Remove(filepath.Join(s.Directory, name))
+}
+
+func (s *spool) quarantine(name string) {
+	path := filepath.Join(s.Directory, name)
+	_ = os.Rename(path, path+".corrupt")
+}
+
+func (b *buffer) replayer(c Config) {
+	defer func() {
+		err := recover()
+		if err != nil {
+			b.report(ErrorEvent{Stage: StageReplay, Err: panicked(err)})
+		}
+	}()
+	for {
+		select {
+		case <-b.replay:
+			b.replaySpool(c)
+		case <-time.After(b.spool.ReplayInterval):
+			b.replaySpool(c)
+		case <-b.done:
+			return
+		}
+	}
+}
+
+func (b *buffer) replaySpool(c Config) {
+	for _, file := range b.spool.files(spoolExtension) {
+		if b.spool.expired(file) {
+			count := b.spool.count(file.Name())
+			b.discard(DroppedByExpired, count)
+			b.spool.remove(file.Name())
+			b.report(ErrorEvent{
+				Stage:   StageReplay,
+				Err:     fmt.Errorf("%v is older than %v", file.Name(), b.spool.MaxAge),
+				Dropped: count,
+			})
+			continue
+		}
+		items, err := b.spool.load(file.Name())
+		if err != nil {
+			b.report(ErrorEvent{
+				Stage: StageReplay,
+				Err:   fmt.Errorf("could not read %v: %v", file.Name(), err),
+			})
+			b.spool.quarantine(file.Name())
+			continue
+		}
+		if err := c.OnOverflow(items); err != nil {
+			return
+		}
+		b.spool.remove(file.Name())
+	}
+}
+
+func (b *buffer) Hold(items []interface{}) (string, error) {
+	if b.spool == nil {
+		return "", nil
+	}
+	return b.spool.hold(items)
+}
+
+func (b *buffer) Release(name string) {
+	if b.spool == nil || len(name) == 0 {
+		return
+	}
+	b.spool.remove(name)
+}
+
+func (b *buffer) Requeue(name string) error {
+	if b.spool == nil || len(name) == 0 {
+		return nil
+	}
+	count := b.spool.count(name)
+	if err := b.spool.requeue(name); err != nil {
+		return err
+	}
+	atomic.AddInt64(&b.spooled, int64(count))
+	b.kickReplay()
+	return nil
+}
+
+func (b *buffer) kickReplay() {
+	if b.spool == nil {
+		return
+	}
+	select {
+	case b.replay <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,271 @@
+package buffer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestSpool_Store(t *testing.T) {
+	t.Parallel()
+	t.Run("when the spool has room", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		subject, err := newSpool(SpoolConfig{Directory: dir})
+		is.Nil(err, "it should create the spool")
+		is.Nil(subject.store([]interface{}{1, "two"}), "it should return no error")
+		is.Nil(subject.store([]interface{}{3}), "it should return no error")
+		files := subject.files(spoolExtension)
+		is.Len(files, 2, "it should write one file per chunk")
+		items, err := subject.load(files[0].Name())
+		is.Nil(err, "it should return no error")
+		is.Equal([]interface{}{json.RawMessage("1"), json.RawMessage(`"two"`)}, items, "it should read the chunks in order")
+		items, err = subject.load(files[1].Name())
+		is.Nil(err, "it should return no error")
+		is.Equal([]interface{}{json.RawMessage("3")}, items, "it should read the chunks in order")
+	})
+	t.Run("when the spool is full", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		subject, _ := newSpool(SpoolConfig{Directory: dir, MaxBytes: 10})
+		is.Nil(subject.store([]interface{}{1}), "it should return no error")
+		is.NotNil(subject.store([]interface{}{"something big"}), "it should return an error")
+		is.Len(subject.files(spoolExtension), 1, "it should not write the chunk")
+	})
+}
+
+func TestBuffer_Spool(t *testing.T) {
+	t.Parallel()
+	t.Run("when the consumer fails and recovers", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		called := make(chan []interface{}, 10)
+		var failed int32
+		subject := New(Config{
+			OnOverflow: func(items []interface{}) error {
+				called <- items
+				if atomic.CompareAndSwapInt32(&failed, 0, 1) {
+					return errors.New("failed")
+				}
+				return nil
+			},
+			BackOff:    time.Minute,
+			Expiration: time.Minute,
+			Cap:        2,
+			OnWait:     10,
+			Spool: SpoolConfig{
+				Directory: dir,
+			},
+		})
+		subject.Write(1)
+		subject.Write(2)
+		is.Equal([]interface{}{1, 2}, <-called, "it should send the chunk")
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		dropped, flushErr := subject.Flush(ctx)
+		is.Nil(flushErr, "it should consider the spooled chunk delivered")
+		is.Equal(0, dropped, "it should drop nothing")
+		subject.Write(3)
+		subject.Write(4)
+		is.ElementsMatch([]interface{}{
+			[]interface{}{3, 4},
+			[]interface{}{json.RawMessage("1"), json.RawMessage("2")},
+		}, []interface{}{<-called, <-called}, "it should send the new chunk and replay the spooled one")
+		time.Sleep(10 * time.Millisecond)
+		files, _ := filepath.Glob(filepath.Join(dir, "*"+spoolExtension))
+		is.Empty(files, "it should remove the replayed chunk")
+	})
+	t.Run("when there are chunks spooled by a previous process", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		previous, _ := newSpool(SpoolConfig{Directory: dir})
+		_ = previous.store([]interface{}{"a"})
+		_ = previous.store([]interface{}{"b"})
+		called := make(chan []interface{}, 10)
+		New(Config{
+			OnOverflow: func(items []interface{}) error {
+				called <- items
+				return nil
+			},
+			Expiration: time.Minute,
+			Spool: SpoolConfig{
+				Directory: dir,
+			},
+		})
+		is.Equal([]interface{}{json.RawMessage(`"a"`)}, <-called, "it should replay the oldest chunk first")
+		is.Equal([]interface{}{json.RawMessage(`"b"`)}, <-called, "it should replay the newest chunk last")
+	})
+	t.Run("when the spooled chunks are too old", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		previous, _ := newSpool(SpoolConfig{Directory: dir})
+		_ = previous.store([]interface{}{"a"})
+		old := time.Now().Add(-time.Hour)
+		for _, file := range previous.files(spoolExtension) {
+			_ = os.Chtimes(filepath.Join(dir, file.Name()), old, old)
+		}
+		called := make(chan []interface{}, 10)
+		New(Config{
+			OnOverflow: func(items []interface{}) error {
+				called <- items
+				return nil
+			},
+			Expiration: time.Minute,
+			Spool: SpoolConfig{
+				Directory: dir,
+				MaxAge:    time.Minute,
+			},
+		})
+		select {
+		case <-called:
+			is.Fail("it should not replay expired chunks")
+		case <-time.After(20 * time.Millisecond):
+		}
+		is.Empty(previous.files(spoolExtension), "it should remove expired chunks")
+	})
+	t.Run("when the traffic stops after an outage", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		called := make(chan []interface{}, 10)
+		var failed int32
+		subject := New(Config{
+			OnOverflow: func(items []interface{}) error {
+				called <- items
+				if atomic.CompareAndSwapInt32(&failed, 0, 1) {
+					return errors.New("failed")
+				}
+				return nil
+			},
+			BackOff:    time.Minute,
+			Expiration: time.Minute,
+			Cap:        1,
+			Spool: SpoolConfig{
+				Directory:      dir,
+				ReplayInterval: 10 * time.Millisecond,
+			},
+		})
+		subject.Write(1)
+		is.Equal([]interface{}{1}, <-called, "it should send the chunk")
+		select {
+		case items := <-called:
+			is.Equal([]interface{}{json.RawMessage("1")}, items, "it should replay the spooled chunk periodically")
+		case <-time.After(time.Second):
+			is.Fail("it should replay the spooled chunk without new writes")
+		}
+	})
+}
+
+func TestBuffer_Hold(t *testing.T) {
+	t.Parallel()
+	t.Run("when the held chunk is released", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		called := make(chan []interface{}, 10)
+		subject := New(Config{
+			OnOverflow: func(items []interface{}) error {
+				called <- items
+				return nil
+			},
+			Expiration: time.Minute,
+			Spool: SpoolConfig{
+				Directory:      dir,
+				ReplayInterval: 5 * time.Millisecond,
+			},
+		}).(*buffer)
+		name, err := subject.Hold([]interface{}{1})
+		is.Nil(err, "it should return no error")
+		select {
+		case <-called:
+			is.Fail("it should not replay a held chunk")
+		case <-time.After(20 * time.Millisecond):
+		}
+		is.Len(subject.spool.files(heldExtension), 1, "it should keep the chunk on disk")
+		subject.Release(name)
+		is.Empty(subject.spool.files(heldExtension), "it should remove the chunk")
+	})
+	t.Run("when the held chunk is requeued", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		called := make(chan []interface{}, 10)
+		subject := New(Config{
+			OnOverflow: func(items []interface{}) error {
+				called <- items
+				return nil
+			},
+			Expiration: time.Minute,
+			Spool: SpoolConfig{
+				Directory: dir,
+			},
+		}).(*buffer)
+		name, _ := subject.Hold([]interface{}{1})
+		is.Nil(subject.Requeue(name), "it should return no error")
+		is.Equal([]interface{}{json.RawMessage("1")}, <-called, "it should replay the chunk")
+		is.Equal(int64(1), subject.Stats().Spooled, "it should count the spooled items")
+	})
+	t.Run("when there are chunks held by a previous process", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		previous, _ := newSpool(SpoolConfig{Directory: dir})
+		_, _ = previous.hold([]interface{}{"a"})
+		called := make(chan []interface{}, 10)
+		New(Config{
+			OnOverflow: func(items []interface{}) error {
+				called <- items
+				return nil
+			},
+			Expiration: time.Minute,
+			Spool: SpoolConfig{
+				Directory: dir,
+			},
+		})
+		is.Equal([]interface{}{json.RawMessage(`"a"`)}, <-called, "it should replay the chunk")
+	})
+	t.Run("when there is no spool", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := New(Config{
+			OnOverflow: func(items []interface{}) error {
+				return nil
+			},
+			Expiration: time.Minute,
+		}).(*buffer)
+		name, err := subject.Hold([]interface{}{1})
+		is.Nil(err, "it should return no error")
+		is.Empty(name, "it should not hold the chunk")
+		is.Nil(subject.Requeue(name), "it should return no error")
+	})
+}
@@ -0,0 +1,43 @@
+package splunk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"sync"
+)
+
+type Compression struct {
+	Gzip  bool
+	Level int
+}
+
+type compressor struct {
+	Compression
+	writers sync.Pool
+}
+
+func (c *compressor) compress(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, _ := c.writers.Get().(*gzip.Writer)
+	if w == nil {
+		level := c.Level
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		var err error
+		w, err = gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		w.Reset(&buf)
+	}
+	defer c.writers.Put(w)
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
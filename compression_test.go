@@ -0,0 +1,72 @@
+package splunk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/icrowley/fake"
+	"github.com/jarcoal/httpmock"
+	"github.com/mundipagg/tracer-splunk-writer/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressor_Compress(t *testing.T) {
+	t.Parallel()
+	t.Run("when the level is valid", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &compressor{
+			Compression: Compression{Gzip: true, Level: gzip.BestSpeed},
+		}
+		for _, input := range []string{"first body", "second body"} {
+			compressed, err := subject.compress([]byte(input))
+			is.Nil(err, "it should return no error")
+			reader, err := gzip.NewReader(bytes.NewReader(compressed))
+			is.Nil(err, "it should return a valid gzip stream")
+			actual, _ := ioutil.ReadAll(reader)
+			is.Equal(input, string(actual), "it should return the compressed body")
+		}
+	})
+	t.Run("when the level is invalid", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &compressor{
+			Compression: Compression{Gzip: true, Level: 42},
+		}
+		_, err := subject.compress([]byte("body"))
+		is.NotNil(err, "it should return an error")
+	})
+}
+
+func TestWriter_Send_Compression(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	c := &http.Client{}
+	activateNonDefault(c)
+	url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+	httpmock.RegisterResponder("POST", url, func(request *http.Request) (response *http.Response, err error) {
+		is.Equal("gzip", request.Header.Get("Content-Encoding"), "it should set the content encoding")
+		reader, err := gzip.NewReader(request.Body)
+		if err != nil {
+			return httpmock.NewBytesResponse(400, nil), nil
+		}
+		body, _ := ioutil.ReadAll(reader)
+		is.Equal(`{"C":15}`, string(body), "it should send the compressed events")
+		return httpmock.NewBytesResponse(200, nil), nil
+	})
+	subject := &Writer{
+		address:    url,
+		client:     c,
+		marshaller: json.New(),
+		compressor: compressor{
+			Compression: Compression{Gzip: true},
+		},
+	}
+	err := subject.send([]interface{}{
+		Entry{"C": 15},
+	})
+	is.Nil(err, "it should return no error")
+}
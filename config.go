@@ -0,0 +1,408 @@
+package splunk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iancoleman/strcase"
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v2"
+)
+
+type ConfigError struct {
+	Source string
+	Field  string
+	Value  interface{}
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	var message string
+	if e.Value == nil {
+		message = fmt.Sprintf("%v: %v", e.Field, e.Err)
+	} else {
+		message = fmt.Sprintf("invalid value %q for %v: %v", fmt.Sprint(e.Value), e.Field, e.Err)
+	}
+	if len(e.Source) == 0 {
+		return message
+	}
+	return e.Source + ": " + message
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	levelType    = reflect.TypeOf(uint8(0))
+	enums        = map[reflect.Type]map[string]uint64{
+		reflect.TypeOf(TimestampFormat(0)): {
+			"epochseconds": uint64(EpochSeconds),
+			"epochmillis":  uint64(EpochMillis),
+			"rfc3339":      uint64(RFC3339),
+		},
+		reflect.TypeOf(OverflowPolicy(0)): {
+			"dropnewest":     uint64(DropNewest),
+			"dropoldest":     uint64(DropOldest),
+			"dropbelowlevel": uint64(DropBelowLevel),
+			"block":          uint64(Block),
+		},
+		reflect.TypeOf(Encoding(0)): {
+			"newlinedelimited": uint64(NewlineDelimited),
+			"concatenated":     uint64(Concatenated),
+			"jsonarray":        uint64(JSONArray),
+		},
+		reflect.TypeOf(Selection(0)): {
+			"roundrobin":    uint64(RoundRobin),
+			"leastfailures": uint64(LeastFailures),
+		},
+		reflect.TypeOf(RedactionStrategy(0)): {
+			"maskall":   uint64(MaskAll),
+			"keeplast4": uint64(KeepLast4),
+			"hash":      uint64(Hash),
+			"remove":    uint64(Remove),
+		},
+		reflect.TypeOf(OversizedPolicy(0)): {
+			"rejectoversized":   uint64(RejectOversized),
+			"truncateoversized": uint64(TruncateOversized),
+		},
+	}
+)
+
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	tree, err := readConfig(path)
+	if err != nil {
+		return config, err
+	}
+	err = decode(path, "", tree, reflect.ValueOf(&config).Elem())
+	return config, err
+}
+
+func ConfigFromEnv(prefix string) (Config, error) {
+	var config Config
+	err := fromEnv(strings.TrimSuffix(prefix, "_"), "", reflect.ValueOf(&config).Elem())
+	return config, err
+}
+
+func readConfig(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	default:
+		err = jsoniter.Unmarshal(data, &tree)
+	}
+	if err != nil {
+		return nil, &ConfigError{Source: path, Field: "file", Err: err}
+	}
+	object, ok := normalize(tree).(map[string]interface{})
+	if !ok {
+		return nil, &ConfigError{Source: path, Field: "file", Err: fmt.Errorf("expected an object")}
+	}
+	return object, nil
+}
+
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[fmt.Sprint(key)] = normalize(item)
+		}
+		return object
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalize(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func fromEnv(prefix string, path string, target reflect.Value) error {
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		if len(field.PkgPath) > 0 || !configurable(field.Type) {
+			continue
+		}
+		name := strcase.ToScreamingSnake(field.Name)
+		if len(prefix) > 0 {
+			name = prefix + "_" + name
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			if err := fromEnv(name, join(path, field.Name), target.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := decode(name, join(path, field.Name), value, target.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func configurable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Func, reflect.Ptr, reflect.Chan:
+		return false
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	case reflect.Slice, reflect.Map:
+		return configurable(t.Elem())
+	}
+	return true
+}
+
+func join(path string, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
+
+func simplify(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
+
+func decode(source string, path string, raw interface{}, target reflect.Value) error {
+	fail := func(err error) error {
+		return &ConfigError{Source: source, Field: path, Value: raw, Err: err}
+	}
+	t := target.Type()
+	if !configurable(t) {
+		return &ConfigError{Source: source, Field: path, Err: fmt.Errorf("can not be configured from %v", source)}
+	}
+	if raw == nil {
+		return nil
+	}
+	if t == durationType {
+		switch v := raw.(type) {
+		case string:
+			d, err := time.ParseDuration(strings.TrimSpace(v))
+			if err != nil {
+				return fail(fmt.Errorf("expected a duration like 10s or 1m30s"))
+			}
+			target.SetInt(int64(d))
+			return nil
+		default:
+			n, err := integer(raw)
+			if err != nil {
+				return fail(fmt.Errorf("expected a duration like 10s or 1m30s"))
+			}
+			target.SetInt(n)
+			return nil
+		}
+	}
+	if names, ok := enums[t]; ok {
+		if name, ok := raw.(string); ok {
+			if value, ok := names[simplify(name)]; ok {
+				target.SetUint(value)
+				return nil
+			}
+			if _, err := strconv.ParseUint(name, 10, 8); err != nil {
+				return fail(fmt.Errorf("unknown %v", t.Name()))
+			}
+		}
+	}
+	if t == levelType {
+		if name, ok := raw.(string); ok {
+			level, err := ParseLevel(name)
+			if err != nil {
+				return fail(err)
+			}
+			target.SetUint(uint64(level))
+			return nil
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		switch raw.(type) {
+		case map[string]interface{}, []interface{}:
+			return fail(fmt.Errorf("expected a string"))
+		}
+		target.SetString(fmt.Sprint(raw))
+	case reflect.Bool:
+		switch v := raw.(type) {
+		case bool:
+			target.SetBool(v)
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return fail(fmt.Errorf("expected true or false"))
+			}
+			target.SetBool(b)
+		default:
+			return fail(fmt.Errorf("expected true or false"))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := integer(raw)
+		if err != nil || target.OverflowInt(n) {
+			return fail(fmt.Errorf("expected an integer"))
+		}
+		target.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := integer(raw)
+		if err != nil || n < 0 || target.OverflowUint(uint64(n)) {
+			return fail(fmt.Errorf("expected a positive integer"))
+		}
+		target.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := float(raw)
+		if err != nil {
+			return fail(fmt.Errorf("expected a number"))
+		}
+		target.SetFloat(f)
+	case reflect.Interface:
+		target.Set(reflect.ValueOf(raw))
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			text, ok := raw.(string)
+			if !ok {
+				return fail(fmt.Errorf("expected a list"))
+			}
+			items = nil
+			if strings.HasPrefix(strings.TrimSpace(text), "[") {
+				if err := jsoniter.UnmarshalFromString(text, &items); err != nil {
+					return fail(fmt.Errorf("expected a JSON list"))
+				}
+				text = ""
+			}
+			for _, item := range strings.Split(text, ",") {
+				if item = strings.TrimSpace(item); len(item) > 0 {
+					items = append(items, item)
+				}
+			}
+		}
+		slice := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := decode(source, fmt.Sprintf("%v[%v]", path, i), item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+	case reflect.Map:
+		object, err := object(raw)
+		if err != nil {
+			return fail(err)
+		}
+		m := reflect.MakeMapWithSize(t, len(object))
+		for key, item := range object {
+			value := reflect.New(t.Elem()).Elem()
+			if err := decode(source, join(path, key), item, value); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), value)
+		}
+		target.Set(m)
+	case reflect.Struct:
+		object, ok := raw.(map[string]interface{})
+		if !ok {
+			return fail(fmt.Errorf("expected an object"))
+		}
+		for key, item := range object {
+			field, ok := fieldByName(t, key)
+			if !ok {
+				return &ConfigError{Source: source, Field: join(path, key), Err: fmt.Errorf("unknown field")}
+			}
+			if err := decode(source, join(path, field.Name), item, target.FieldByIndex(field.Index)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fail(fmt.Errorf("unsupported type %v", t))
+	}
+	return nil
+}
+
+func fieldByName(t reflect.Type, name string) (reflect.StructField, bool) {
+	name = simplify(name)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) == 0 && simplify(field.Name) == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func object(raw interface{}) (map[string]interface{}, error) {
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case string:
+		object := map[string]interface{}{}
+		if strings.HasPrefix(strings.TrimSpace(v), "{") {
+			if err := jsoniter.UnmarshalFromString(v, &object); err != nil {
+				return nil, fmt.Errorf("expected a JSON object")
+			}
+			return object, nil
+		}
+		for _, pair := range strings.Split(v, ",") {
+			if pair = strings.TrimSpace(pair); len(pair) == 0 {
+				continue
+			}
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("expected key=value pairs separated by commas")
+			}
+			object[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("expected an object")
+}
+
+func integer(raw interface{}) (int64, error) {
+	switch v := raw.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		return int64(v), nil
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, fmt.Errorf("not an integer")
+		}
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	}
+	return 0, fmt.Errorf("not an integer")
+}
+
+func float(raw interface{}) (float64, error) {
+	switch v := raw.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	return 0, fmt.Errorf("not a number")
+}
@@ -0,0 +1,205 @@
+package splunk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mralves/tracer"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+	"github.com/stretchr/testify/assert"
+)
+
+func configFile(name string, content string) string {
+	dir, _ := ioutil.TempDir("", "splunk-config")
+	path := filepath.Join(dir, name)
+	_ = ioutil.WriteFile(path, []byte(content), 0600)
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+	expected := Config{
+		Address:      "http://localhost:8088/services/collector",
+		Addresses:    []string{"http://other:8088/services/collector"},
+		Key:          "token",
+		Application:  "payments",
+		MinimumLevel: tracer.Warning,
+		Levels: map[string]uint8{
+			"payments.gateway": tracer.Debug,
+		},
+		Timeout: 10 * time.Second,
+		Buffer: buffer.Config{
+			Cap:        50,
+			Expiration: time.Minute,
+			Spool: buffer.SpoolConfig{
+				Directory: "/var/spool/splunk",
+				MaxAge:    90 * time.Minute,
+			},
+		},
+		ConfigLineLog: Entry{
+			"index": "main",
+			"host":  "pod",
+		},
+		TimestampFormat: EpochMillis,
+		Queue: QueueConfig{
+			Overflow: DropBelowLevel,
+			Level:    tracer.Error,
+		},
+		Compression: Compression{Gzip: true},
+		TLS:         TLS{InsecureSkipVerify: true},
+	}
+	t.Run("when the file is JSON", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		path := configFile("splunk.json", `{
+			"Address": "http://localhost:8088/services/collector",
+			"Addresses": ["http://other:8088/services/collector"],
+			"Key": "token",
+			"Application": "payments",
+			"MinimumLevel": "warning",
+			"Levels": {"payments.gateway": "debug"},
+			"Timeout": "10s",
+			"Buffer": {
+				"Cap": 50,
+				"Expiration": "1m",
+				"Spool": {"Directory": "/var/spool/splunk", "MaxAge": "1h30m"}
+			},
+			"ConfigLineLog": {"index": "main", "host": "pod"},
+			"TimestampFormat": "EpochMillis",
+			"Queue": {"Overflow": "DropBelowLevel", "Level": "error"},
+			"Compression": {"Gzip": true},
+			"TLS": {"InsecureSkipVerify": true}
+		}`)
+		defer os.RemoveAll(filepath.Dir(path))
+		actual, err := LoadConfig(path)
+		is.Nil(err, "it should return no error")
+		is.Equal(expected, actual, "it should load every field")
+	})
+	t.Run("when the file is YAML", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		path := configFile("splunk.yaml", `
+address: http://localhost:8088/services/collector
+addresses:
+  - http://other:8088/services/collector
+key: token
+application: payments
+minimum_level: warning
+levels:
+  payments.gateway: debug
+timeout: 10s
+buffer:
+  cap: 50
+  expiration: 1m
+  spool:
+    directory: /var/spool/splunk
+    max_age: 1h30m
+config_line_log:
+  index: main
+  host: pod
+timestamp_format: epoch_millis
+queue:
+  overflow: drop_below_level
+  level: 3
+compression:
+  gzip: true
+tls:
+  insecure_skip_verify: true
+`)
+		defer os.RemoveAll(filepath.Dir(path))
+		actual, err := LoadConfig(path)
+		is.Nil(err, "it should return no error")
+		is.Equal(expected, actual, "it should load every field")
+	})
+	t.Run("when the file is invalid", func(t *testing.T) {
+		t.Parallel()
+		cases := map[string]string{
+			`{"Timeout": "ten seconds"}`:      `invalid value "ten seconds" for Timeout: expected a duration like 10s or 1m30s`,
+			`{"MinimumLevel": "loud"}`:        `invalid value "loud" for MinimumLevel: unknown level "loud"`,
+			`{"Buffer": {"Cap": "many"}}`:     `invalid value "many" for Buffer.Cap: expected an integer`,
+			`{"Buffer": {"Capacity": 10}}`:    `Buffer.Capacity: unknown field`,
+			`{"Levels": {"payments": "x"}}`:   `invalid value "x" for Levels.payments: unknown level "x"`,
+			`{"Queue": {"Overflow": "drop"}}`: `invalid value "drop" for Queue.Overflow: unknown OverflowPolicy`,
+			`{"ErrorHandler": "stderr"}`:      `ErrorHandler: can not be configured from`,
+			`{"Compression": {"Gzip": 1}}`:    `invalid value "1" for Compression.Gzip: expected true or false`,
+			`["http://localhost:8088"]`:       `file: expected an object`,
+			`{"Address": `:                    `file:`,
+		}
+		for content, message := range cases {
+			content, message := content, message
+			t.Run("when the content is "+content, func(t *testing.T) {
+				t.Parallel()
+				is := assert.New(t)
+				path := configFile("splunk.json", content)
+				defer os.RemoveAll(filepath.Dir(path))
+				_, err := LoadConfig(path)
+				is.IsType(&ConfigError{}, err, "it should return a config error")
+				if err != nil {
+					is.Contains(err.Error(), message, "it should describe the error")
+					is.Contains(err.Error(), path, "it should name the file")
+				}
+			})
+		}
+	})
+	t.Run("when the file does not exist", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		_, err := LoadConfig(filepath.Join(os.TempDir(), "missing-splunk.json"))
+		is.NotNil(err, "it should return an error")
+	})
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Parallel()
+	t.Run("when the variables are valid", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		env := map[string]string{
+			"SPLUNK_ENV_ADDRESS":                           "http://localhost:8088/services/collector",
+			"SPLUNK_ENV_ADDRESSES":                         "http://a:8088, http://b:8088",
+			"SPLUNK_ENV_MINIMUM_LEVEL":                     "Information",
+			"SPLUNK_ENV_LEVELS":                            "payments.gateway=debug,orders=error",
+			"SPLUNK_ENV_TIMEOUT":                           "5s",
+			"SPLUNK_ENV_BUFFER_CAP":                        "20",
+			"SPLUNK_ENV_BUFFER_SPOOL_MAX_BYTES":            "1048576",
+			"SPLUNK_ENV_CONFIG_LINE_LOG":                   `{"index": "main"}`,
+			"SPLUNK_ENV_TLS_CA_FILE":                       "/etc/ssl/ca.pem",
+			"SPLUNK_ENV_TRANSPORT_MAX_IDLE_CONNS_PER_HOST": "8",
+			"SPLUNK_ENV_APPLICATION_AS_SOURCE":             "true",
+			"SPLUNK_ENV_REDACTION":                         `[{"keys": ["cvv", "card.number"], "strategy": "keep_last4"}]`,
+		}
+		for name, value := range env {
+			_ = os.Setenv(name, value)
+			defer os.Unsetenv(name)
+		}
+		actual, err := ConfigFromEnv("SPLUNK_ENV_")
+		is.Nil(err, "it should return no error")
+		is.Equal(Config{
+			Address:             "http://localhost:8088/services/collector",
+			Addresses:           []string{"http://a:8088", "http://b:8088"},
+			MinimumLevel:        tracer.Informational,
+			Levels:              map[string]uint8{"payments.gateway": tracer.Debug, "orders": tracer.Error},
+			Timeout:             5 * time.Second,
+			Buffer:              buffer.Config{Cap: 20, Spool: buffer.SpoolConfig{MaxBytes: 1048576}},
+			ConfigLineLog:       Entry{"index": "main"},
+			TLS:                 TLS{CAFile: "/etc/ssl/ca.pem"},
+			Transport:           Transport{MaxIdleConnsPerHost: 8},
+			ApplicationAsSource: true,
+			Redaction:           []RedactionRule{{Keys: []string{"cvv", "card.number"}, Strategy: KeepLast4}},
+		}, actual, "it should load every variable")
+	})
+	t.Run("when a variable is invalid", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		_ = os.Setenv("SPLUNK_BAD_BUFFER_EXPIRATION", "soon")
+		defer os.Unsetenv("SPLUNK_BAD_BUFFER_EXPIRATION")
+		_, err := ConfigFromEnv("SPLUNK_BAD")
+		is.NotNil(err, "it should return an error")
+		if err != nil {
+			is.Equal(`SPLUNK_BAD_BUFFER_EXPIRATION: invalid value "soon" for Buffer.Expiration: expected a duration like 10s or 1m30s`, err.Error(), "it should name the variable")
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package splunk
+
+import (
+	"bytes"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+type Encoding uint8
+
+const (
+	NewlineDelimited Encoding = iota
+	Concatenated
+	JSONArray
+)
+
+type encoder struct {
+	Encoding
+	format  func(interface{}) string
+	buffers sync.Pool
+}
+
+func (e *encoder) encode(api jsoniter.API, events []interface{}) (*bytes.Buffer, error) {
+	buf, _ := e.buffers.Get().(*bytes.Buffer)
+	if buf == nil {
+		buf = &bytes.Buffer{}
+	}
+	buf.Reset()
+	if e.format != nil {
+		for i, event := range events {
+			if i > 0 {
+				buf.WriteByte('\n')
+			}
+			buf.WriteString(e.format(event))
+		}
+		return buf, nil
+	}
+	stream := api.BorrowStream(buf)
+	defer api.ReturnStream(stream)
+	if e.Encoding == JSONArray {
+		stream.WriteArrayStart()
+	}
+	for i, event := range events {
+		if i > 0 {
+			switch e.Encoding {
+			case NewlineDelimited:
+				stream.WriteRaw("\n")
+			case JSONArray:
+				stream.WriteMore()
+			}
+		}
+		stream.WriteVal(event)
+		if stream.Error == nil {
+			stream.Flush()
+		}
+		if stream.Error != nil {
+			e.release(buf)
+			return nil, stream.Error
+		}
+	}
+	if e.Encoding == JSONArray {
+		stream.WriteArrayEnd()
+	}
+	if err := stream.Flush(); err != nil {
+		e.release(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (e *encoder) release(buf *bytes.Buffer) {
+	e.buffers.Put(buf)
+}
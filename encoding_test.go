@@ -0,0 +1,100 @@
+package splunk
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/icrowley/fake"
+	"github.com/jarcoal/httpmock"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+	"github.com/mundipagg/tracer-splunk-writer/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder_Encode(t *testing.T) {
+	t.Parallel()
+	events := []interface{}{
+		Entry{"A": 1},
+		Entry{"B": "2"},
+	}
+	cases := map[Encoding]string{
+		NewlineDelimited: "{\"A\":1}\n{\"B\":\"2\"}",
+		Concatenated:     `{"A":1}{"B":"2"}`,
+		JSONArray:        `[{"A":1},{"B":"2"}]`,
+	}
+	for encoding, expected := range cases {
+		encoding, expected := encoding, expected
+		t.Run("when the encoding is "+expected, func(t *testing.T) {
+			t.Parallel()
+			is := assert.New(t)
+			subject := &encoder{Encoding: encoding}
+			for i := 0; i < 2; i++ {
+				actual, err := subject.encode(json.New(), events)
+				is.Nil(err, "it should return no error")
+				is.Equal(expected, actual.String(), "it should encode the events")
+				subject.release(actual)
+			}
+		})
+	}
+	t.Run("when there are no events", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &encoder{Encoding: JSONArray}
+		actual, err := subject.encode(json.New(), nil)
+		is.Nil(err, "it should return no error")
+		is.Equal("[]", actual.String(), "it should encode an empty array")
+	})
+	t.Run("when an event can not be encoded", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &encoder{}
+		actual, err := subject.encode(json.New(), []interface{}{1, make(chan int)})
+		is.NotNil(err, "it should return an error")
+		is.Nil(actual, "it should return no body")
+	})
+}
+
+func TestWriter_Send_Encoding(t *testing.T) {
+	t.Parallel()
+	t.Run("when the encoding is a json array", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		c := &http.Client{}
+		activateNonDefault(c)
+		url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+		var bodies []string
+		httpmock.RegisterResponder("POST", url, func(request *http.Request) (response *http.Response, err error) {
+			body, _ := ioutil.ReadAll(request.Body)
+			bodies = append(bodies, string(body))
+			return httpmock.NewBytesResponse(200, nil), nil
+		})
+		subject := &Writer{
+			address:    url,
+			client:     c,
+			marshaller: json.New(),
+			encoder:    encoder{Encoding: JSONArray},
+		}
+		err := subject.send([]interface{}{1, 2})
+		is.Nil(err, "it should return no error")
+		is.Equal([]string{"[1,2]"}, bodies, "it should send a json array")
+	})
+	t.Run("when an event can not be encoded", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		var reported []ErrorEvent
+		subject := &Writer{
+			marshaller: json.New(),
+			errorHandler: func(e ErrorEvent) {
+				reported = append(reported, e)
+			},
+		}
+		err := subject.send([]interface{}{make(chan int)})
+		is.NotNil(err, "it should return an error")
+		is.Equal([]ErrorEvent{{
+			Stage:     buffer.StageEncode,
+			BatchSize: 1,
+			Err:       err,
+		}}, reported, "it should report the error")
+	})
+}
@@ -0,0 +1,208 @@
+package splunk
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxFailures   = 3
+	DefaultProbeInterval = 30 * time.Second
+)
+
+type Selection uint8
+
+const (
+	RoundRobin Selection = iota
+	LeastFailures
+)
+
+type Balancing struct {
+	Selection     Selection
+	MaxFailures   int
+	ProbeInterval time.Duration
+}
+
+type EndpointStats struct {
+	Address             string
+	Requests            uint64
+	Failures            uint64
+	ConsecutiveFailures int
+	Ejected             bool
+	LastError           error
+}
+
+type endpoint struct {
+	address     string
+	health      string
+	requests    uint64
+	failures    uint64
+	consecutive int
+	ejected     bool
+	lastError   error
+}
+
+type endpoints struct {
+	sync.Mutex
+	Balancing
+	list []*endpoint
+	next int
+	once sync.Once
+	done chan struct{}
+}
+
+func newEndpoints(addresses []string, config Balancing) *endpoints {
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = DefaultMaxFailures
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = DefaultProbeInterval
+	}
+	e := &endpoints{
+		Balancing: config,
+		done:      make(chan struct{}),
+	}
+	for _, address := range addresses {
+		e.list = append(e.list, &endpoint{
+			address: address,
+			health:  collectorAddress(address, "health"),
+		})
+	}
+	return e
+}
+
+func (e *endpoints) pick() *endpoint {
+	if e == nil || len(e.list) == 0 {
+		return nil
+	}
+	e.Lock()
+	defer e.Unlock()
+	all := !e.any(func(ep *endpoint) bool {
+		return !ep.ejected
+	})
+	var picked *endpoint
+	index := e.next
+	for i := 0; i < len(e.list); i++ {
+		ep := e.list[(e.next+i)%len(e.list)]
+		if ep.ejected && !all {
+			continue
+		}
+		if picked == nil || (e.Selection == LeastFailures && ep.consecutive < picked.consecutive) {
+			picked = ep
+			index = (e.next + i) % len(e.list)
+		}
+		if e.Selection == RoundRobin {
+			break
+		}
+	}
+	e.next = (index + 1) % len(e.list)
+	return picked
+}
+
+func (e *endpoints) any(match func(*endpoint) bool) bool {
+	for _, ep := range e.list {
+		if match(ep) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sw *Writer) observe(target *endpoint, status int, err error) {
+	if target == nil {
+		return
+	}
+	e := sw.endpoints
+	e.Lock()
+	defer e.Unlock()
+	target.requests++
+	if err == nil && status < 500 {
+		target.consecutive = 0
+		return
+	}
+	if err == nil {
+		err = &HECError{Status: status}
+	}
+	target.failures++
+	target.consecutive++
+	target.lastError = err
+	if len(e.list) > 1 && target.consecutive >= e.MaxFailures && !target.ejected {
+		target.ejected = true
+		e.once.Do(func() {
+			go sw.probe()
+		})
+	}
+}
+
+func (sw *Writer) probe() {
+	e := sw.endpoints
+	ticker := time.NewTicker(e.ProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			sw.probeEjected()
+		case <-e.done:
+			return
+		}
+	}
+}
+
+func (sw *Writer) probeEjected() {
+	e := sw.endpoints
+	e.Lock()
+	var ejected []*endpoint
+	for _, ep := range e.list {
+		if ep.ejected {
+			ejected = append(ejected, ep)
+		}
+	}
+	e.Unlock()
+	for _, ep := range ejected {
+		if !sw.healthy(ep) {
+			continue
+		}
+		e.Lock()
+		ep.ejected = false
+		ep.consecutive = 0
+		e.Unlock()
+	}
+}
+
+func (sw *Writer) healthy(ep *endpoint) bool {
+	request, err := http.NewRequest(http.MethodGet, ep.health, nil)
+	if err != nil {
+		return false
+	}
+	response, err := sw.client.Do(request)
+	if err != nil {
+		return false
+	}
+	defer response.Body.Close()
+	return response.StatusCode == 200
+}
+
+func (e *endpoints) stats() []EndpointStats {
+	if e == nil {
+		return nil
+	}
+	e.Lock()
+	defer e.Unlock()
+	stats := make([]EndpointStats, 0, len(e.list))
+	for _, ep := range e.list {
+		stats = append(stats, EndpointStats{
+			Address:             ep.address,
+			Requests:            ep.requests,
+			Failures:            ep.failures,
+			ConsecutiveFailures: ep.consecutive,
+			Ejected:             ep.ejected,
+			LastError:           ep.lastError,
+		})
+	}
+	return stats
+}
+
+func (e *endpoints) stop() {
+	close(e.done)
+}
@@ -0,0 +1,164 @@
+package splunk
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/icrowley/fake"
+	"github.com/jarcoal/httpmock"
+	"github.com/mundipagg/tracer-splunk-writer/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func picks(subject *endpoints, n int) []string {
+	var addresses []string
+	for i := 0; i < n; i++ {
+		addresses = append(addresses, subject.pick().address)
+	}
+	return addresses
+}
+
+func TestEndpoints_Pick(t *testing.T) {
+	t.Parallel()
+	t.Run("when there are no endpoints", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		var subject *endpoints
+		is.Nil(subject.pick(), "it should return nil")
+	})
+	t.Run("when the selection is round robin", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := newEndpoints([]string{"a", "b", "c"}, Balancing{})
+		is.Equal([]string{"a", "b", "c", "a"}, picks(subject, 4), "it should rotate the endpoints")
+	})
+	t.Run("when an endpoint is ejected", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := newEndpoints([]string{"a", "b", "c"}, Balancing{})
+		subject.list[1].ejected = true
+		is.Equal([]string{"a", "c", "a", "c"}, picks(subject, 4), "it should skip the ejected endpoint")
+	})
+	t.Run("when every endpoint is ejected", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := newEndpoints([]string{"a", "b"}, Balancing{})
+		subject.list[0].ejected = true
+		subject.list[1].ejected = true
+		is.Equal([]string{"a", "b"}, picks(subject, 2), "it should use every endpoint")
+	})
+	t.Run("when the selection is least failures", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := newEndpoints([]string{"a", "b", "c"}, Balancing{Selection: LeastFailures})
+		subject.list[0].consecutive = 2
+		subject.list[1].consecutive = 1
+		is.Equal([]string{"c", "c", "c"}, picks(subject, 3), "it should use the endpoint with less failures")
+		subject.list[2].consecutive = 1
+		is.Equal([]string{"b", "c"}, picks(subject, 2), "it should rotate the endpoints with the same failures")
+	})
+}
+
+func TestWriter_Observe(t *testing.T) {
+	t.Parallel()
+	t.Run("when an endpoint fails too many times", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &Writer{
+			endpoints: newEndpoints([]string{"a", "b"}, Balancing{MaxFailures: 2}),
+		}
+		defer subject.endpoints.stop()
+		target := subject.endpoints.list[0]
+		subject.observe(target, 0, errors.New("refused"))
+		is.False(target.ejected, "it should not eject the endpoint before the threshold")
+		subject.observe(target, 200, nil)
+		subject.observe(target, 503, nil)
+		subject.observe(target, 0, errors.New("refused"))
+		is.True(target.ejected, "it should eject the endpoint")
+		is.Equal([]EndpointStats{{
+			Address:             "a",
+			Requests:            4,
+			Failures:            3,
+			ConsecutiveFailures: 2,
+			Ejected:             true,
+			LastError:           errors.New("refused"),
+		}, {
+			Address: "b",
+		}}, subject.endpoints.stats(), "it should return the endpoint stats")
+	})
+	t.Run("when there is a single endpoint", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &Writer{
+			endpoints: newEndpoints([]string{"a"}, Balancing{MaxFailures: 1}),
+		}
+		target := subject.endpoints.list[0]
+		subject.observe(target, 500, nil)
+		is.False(target.ejected, "it should never eject the endpoint")
+		is.Equal(&HECError{Status: 500}, target.lastError, "it should keep the status as the last error")
+	})
+}
+
+func TestWriter_ProbeEjected(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	c := &http.Client{}
+	activateNonDefault(c)
+	url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+	httpmock.RegisterResponder("GET", url+"/a/health", httpmock.NewStringResponder(200, `{"text":"HEC is healthy","code":17}`))
+	httpmock.RegisterResponder("GET", url+"/b/health", httpmock.NewStringResponder(503, `{"text":"HEC is unhealthy","code":18}`))
+	subject := &Writer{
+		client:    c,
+		endpoints: newEndpoints([]string{url + "/a/event", url + "/b/event"}, Balancing{}),
+	}
+	for _, ep := range subject.endpoints.list {
+		ep.ejected = true
+		ep.consecutive = 3
+	}
+	subject.probeEjected()
+	is.False(subject.endpoints.list[0].ejected, "it should admit the healthy endpoint")
+	is.Equal(0, subject.endpoints.list[0].consecutive, "it should reset the failures of the healthy endpoint")
+	is.True(subject.endpoints.list[1].ejected, "it should keep the unhealthy endpoint ejected")
+}
+
+func TestWriter_Send_Endpoints(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	c := &http.Client{}
+	activateNonDefault(c)
+	url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+	httpmock.RegisterResponder("POST", url+"/a", httpmock.NewStringResponder(503, `{"text":"Server is busy","code":9}`))
+	httpmock.RegisterResponder("POST", url+"/b", httpmock.NewBytesResponder(200, nil))
+	subject := &Writer{
+		client:     c,
+		marshaller: json.New(),
+		endpoints:  newEndpoints([]string{url + "/a", url + "/b"}, Balancing{MaxFailures: 1}),
+	}
+	defer subject.endpoints.stop()
+	is.NotNil(subject.send([]interface{}{1}), "it should fail on the first endpoint")
+	is.Nil(subject.send([]interface{}{2}), "it should send to the second endpoint")
+	is.Nil(subject.send([]interface{}{3}), "it should not use the ejected endpoint")
+	stats := subject.Stats().Endpoints
+	is.Equal(uint64(1), stats[0].Requests, "it should count the requests of the first endpoint")
+	is.True(stats[0].Ejected, "it should eject the first endpoint")
+	is.Equal(uint64(2), stats[1].Requests, "it should count the requests of the second endpoint")
+}
+
+func TestNew_Addresses(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	subject := New(Config{
+		Address:   "http://a:8088/services/collector/raw",
+		Addresses: []string{"http://a:8088/services/collector/raw", "http://b:8088/services/collector/raw"},
+		ConfigLineLog: Entry{
+			"index": "main",
+		},
+		Raw: Raw{Enabled: true},
+	})
+	is.Equal("http://a:8088/services/collector/raw?index=main", subject.address, "it should use the first address")
+	is.Equal([]EndpointStats{
+		{Address: "http://a:8088/services/collector/raw?index=main"},
+		{Address: "http://b:8088/services/collector/raw?index=main"},
+	}, subject.Stats().Endpoints, "it should use every address")
+}
@@ -0,0 +1,51 @@
+package splunk
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mralves/tracer"
+)
+
+type Enrichment struct {
+	Caller          bool
+	Owner           bool
+	StackTrace      bool
+	StackTraceLevel uint8
+	Depth           int
+	TrimPath        string
+}
+
+func (e Enrichment) properties(entry tracer.Entry) Entry {
+	properties := Entry{}
+	if e.Owner && len(entry.Owner) > 0 {
+		properties.Add("Owner", entry.Owner)
+	}
+	if len(entry.StackTrace) == 0 {
+		return properties
+	}
+	if e.Caller {
+		properties.Add("Caller", e.frame(entry.StackTrace[0]))
+	}
+	if e.StackTrace && entry.Level <= e.StackTraceLevel {
+		frames := entry.StackTrace
+		if e.Depth > 0 && len(frames) > e.Depth {
+			frames = frames[:e.Depth]
+		}
+		lines := make([]string, len(frames))
+		for i, frame := range frames {
+			lines[i] = e.frame(frame)
+		}
+		properties.Add("StackTrace", strings.Join(lines, "\n"))
+	}
+	return properties
+}
+
+func (e Enrichment) frame(caller tracer.Caller) string {
+	file := path.Base(caller.File)
+	if len(e.TrimPath) > 0 {
+		file = strings.TrimPrefix(strings.TrimPrefix(caller.File, e.TrimPath), "/")
+	}
+	return fmt.Sprintf("at %s(%s:%d)", caller.Function, file, caller.Line)
+}
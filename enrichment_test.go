@@ -0,0 +1,83 @@
+package splunk
+
+import (
+	"testing"
+
+	"github.com/mralves/tracer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrichment_Properties(t *testing.T) {
+	t.Parallel()
+	stackTrace := tracer.StackTrace{
+		{File: "/go/src/app/payments/gateway.go", Function: "app/payments.Pay", Line: 42},
+		{File: "/go/src/app/main.go", Function: "main.main", Line: 10},
+	}
+	t.Run("when nothing is enabled", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := Enrichment{}
+		actual := subject.properties(tracer.Entry{
+			Level:      tracer.Fatal,
+			Owner:      "payments",
+			StackTrace: stackTrace,
+		})
+		is.Equal(Entry{}, actual, "it should return no properties")
+	})
+	t.Run("when the entry is below the stack trace level", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := Enrichment{
+			Caller:          true,
+			Owner:           true,
+			StackTrace:      true,
+			StackTraceLevel: tracer.Error,
+		}
+		actual := subject.properties(tracer.Entry{
+			Level:      tracer.Warning,
+			Owner:      "payments",
+			StackTrace: stackTrace,
+		})
+		expected := Entry{
+			"Caller": "at app/payments.Pay(gateway.go:42)",
+			"Owner":  "payments",
+		}
+		is.Equal(expected, actual, "it should return only the caller and the owner")
+	})
+	t.Run("when the entry is at the stack trace level", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := Enrichment{
+			Caller:          true,
+			StackTrace:      true,
+			StackTraceLevel: tracer.Error,
+			TrimPath:        "/go/src",
+		}
+		actual := subject.properties(tracer.Entry{
+			Level:      tracer.Error,
+			StackTrace: stackTrace,
+		})
+		expected := Entry{
+			"Caller":     "at app/payments.Pay(app/payments/gateway.go:42)",
+			"StackTrace": "at app/payments.Pay(app/payments/gateway.go:42)\nat main.main(app/main.go:10)",
+		}
+		is.Equal(expected, actual, "it should return the caller and the trimmed stack trace")
+	})
+	t.Run("when the depth is limited", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := Enrichment{
+			StackTrace:      true,
+			StackTraceLevel: tracer.Fatal,
+			Depth:           1,
+		}
+		actual := subject.properties(tracer.Entry{
+			Level:      tracer.Fatal,
+			StackTrace: stackTrace,
+		})
+		expected := Entry{
+			"StackTrace": "at app/payments.Pay(gateway.go:42)",
+		}
+		is.Equal(expected, actual, "it should return only the first frames")
+	})
+}
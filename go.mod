@@ -8,8 +8,9 @@ require (
 	github.com/icrowley/fake v0.0.0-20180203215853-4178557ae428
 	github.com/jarcoal/httpmock v1.0.4
 	github.com/json-iterator/go v1.1.8
-	github.com/modern-go/reflect2 v1.0.1
+	github.com/modern-go/reflect2 v1.0.2
 	github.com/mralves/tracer v1.7.3
 	github.com/stretchr/testify v1.4.0
 	github.com/v2pro/plz v0.0.0-20180227161703-2d49b86ea382 // indirect
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -0,0 +1,129 @@
+package splunk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+type Category uint8
+
+const (
+	Retryable Category = iota
+	NonRetryable
+	Partial
+)
+
+var hecCategories = map[int]Category{
+	1:  NonRetryable, // token disabled
+	2:  NonRetryable, // token is required
+	3:  NonRetryable, // invalid authorization
+	4:  NonRetryable, // invalid token
+	5:  NonRetryable, // no data
+	6:  Partial,      // invalid data format
+	7:  NonRetryable, // incorrect index
+	8:  Retryable,    // internal server error
+	9:  Retryable,    // server is busy
+	10: NonRetryable, // data channel is missing
+	11: NonRetryable, // invalid data channel
+	12: Partial,      // event field is required
+	13: Partial,      // event field cannot be blank
+	14: NonRetryable, // ACK is disabled
+	15: Partial,      // error in handling indexed fields
+	16: NonRetryable, // query string authorization is not enabled
+	18: Retryable,    // queues are full
+	19: Retryable,    // ack service unavailable
+	20: Retryable,    // queues are full and ack service unavailable
+}
+
+type HECError struct {
+	Status       int
+	Code         int
+	Text         string
+	InvalidEvent int
+	Category     Category
+	Delay        time.Duration
+}
+
+func (e *HECError) Error() string {
+	if len(e.Text) > 0 {
+		return fmt.Sprintf("request returned %v: %v (code %v)", e.Status, e.Text, e.Code)
+	}
+	return fmt.Sprintf("request returned %v", e.Status)
+}
+
+func (e *HECError) Retryable() bool {
+	return e.Category == Retryable
+}
+
+func (e *HECError) RetryAfter() time.Duration {
+	return e.Delay
+}
+
+func (e *HECError) Reason() string {
+	return fmt.Sprintf("status_%v", e.Status)
+}
+
+func newHECError(response *http.Response) *HECError {
+	e := &HECError{
+		Status:       response.StatusCode,
+		Code:         -1,
+		InvalidEvent: -1,
+		Category:     statusCategory(response.StatusCode),
+		Delay:        parseRetryAfter(response.Header.Get("Retry-After")),
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil || len(body) == 0 {
+		return e
+	}
+	var parsed struct {
+		Text         string `json:"text"`
+		Code         *int   `json:"code"`
+		InvalidEvent *int   `json:"invalid-event-number"`
+	}
+	if jsoniter.Unmarshal(body, &parsed) != nil || parsed.Code == nil {
+		return e
+	}
+	e.Text = parsed.Text
+	e.Code = *parsed.Code
+	if category, ok := hecCategories[e.Code]; ok {
+		e.Category = category
+	}
+	if parsed.InvalidEvent != nil {
+		e.InvalidEvent = *parsed.InvalidEvent
+	}
+	if e.Category == Partial && e.InvalidEvent < 0 {
+		e.Category = NonRetryable
+	}
+	return e
+}
+
+func statusCategory(status int) Category {
+	switch {
+	case status == http.StatusRequestTimeout, status == http.StatusTooManyRequests, status >= 500:
+		return Retryable
+	case status >= 400:
+		return NonRetryable
+	default:
+		return Retryable
+	}
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+package splunk
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHECError(t *testing.T) {
+	t.Parallel()
+	t.Run("when the body is empty", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		cases := map[int]Category{
+			http.StatusBadRequest:          NonRetryable,
+			http.StatusForbidden:           NonRetryable,
+			http.StatusRequestTimeout:      Retryable,
+			http.StatusTooManyRequests:     Retryable,
+			http.StatusInternalServerError: Retryable,
+			http.StatusBadGateway:          Retryable,
+		}
+		for status, expected := range cases {
+			actual := newHECError(httpmock.NewBytesResponse(status, nil))
+			is.Equal(expected, actual.Category, "it should classify by the status")
+			is.Equal(-1, actual.Code, "it should have no code")
+		}
+	})
+	t.Run("when the body has a code", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		response := httpmock.NewStringResponse(http.StatusServiceUnavailable, `{"text":"Server is busy","code":9}`)
+		response.Header.Set("Retry-After", "5")
+		actual := newHECError(response)
+		expected := &HECError{
+			Status:       http.StatusServiceUnavailable,
+			Code:         9,
+			Text:         "Server is busy",
+			InvalidEvent: -1,
+			Category:     Retryable,
+			Delay:        5 * time.Second,
+		}
+		is.Equal(expected, actual, "it should parse the body")
+		is.True(actual.Retryable(), "it should be retryable")
+		is.Equal(5*time.Second, actual.RetryAfter(), "it should honour the Retry-After header")
+	})
+	t.Run("when the token is invalid", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual := newHECError(httpmock.NewStringResponse(http.StatusForbidden, `{"text":"Invalid token","code":4}`))
+		is.False(actual.Retryable(), "it should not be retryable")
+	})
+	t.Run("when an event is invalid", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual := newHECError(httpmock.NewStringResponse(http.StatusBadRequest, `{"text":"Invalid data format","code":6,"invalid-event-number":2}`))
+		is.Equal(Partial, actual.Category, "it should be partial")
+		is.Equal(2, actual.InvalidEvent, "it should return the invalid event")
+		is.False(actual.Retryable(), "it should not be retryable")
+	})
+	t.Run("when the invalid event is unknown", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual := newHECError(httpmock.NewStringResponse(http.StatusBadRequest, `{"text":"Invalid data format","code":6}`))
+		is.Equal(NonRetryable, actual.Category, "it should not be partial")
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	is.Equal(time.Duration(0), parseRetryAfter(""), "it should return zero when empty")
+	is.Equal(time.Duration(0), parseRetryAfter("invalid"), "it should return zero when invalid")
+	is.Equal(30*time.Second, parseRetryAfter("30"), "it should parse seconds")
+	actual := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	is.True(actual > 58*time.Second && actual <= time.Minute, "it should parse dates")
+}
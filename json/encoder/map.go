@@ -9,6 +9,7 @@ import (
 
 type Map struct {
 	Strategy func(string) string
+	OnError  func(error)
 }
 
 func (enc *Map) IsEmpty(ptr unsafe.Pointer) bool {
@@ -21,7 +22,7 @@ func (enc *Map) Encode(ptr unsafe.Pointer, stream *jsoniter.Stream) {
 	defer func() {
 		err := recover()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "a error occurred while serialization of 'map', error: '%v'", err)
+			report(enc.OnError, fmt.Errorf("a error occurred while serialization of 'map', error: '%v'", err))
 			stream.SetBuffer(beforeBuffer)
 		}
 	}()
@@ -32,3 +33,11 @@ func (enc *Map) Encode(ptr unsafe.Pointer, stream *jsoniter.Stream) {
 		stream.WriteString(enc.Strategy(*s))
 	}
 }
+
+func report(onError func(error), err error) {
+	if onError == nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	onError(err)
+}
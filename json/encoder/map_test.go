@@ -51,6 +51,25 @@ func TestMap_Encode(t *testing.T) {
 		_ = stream.Flush()
 		is.Equal(strconv.Quote(expected), buf.String())
 	})
+	t.Run("when the strategy fails", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		var reported error
+		subject := &Map{
+			Strategy: func(s string) string {
+				panic("failed")
+			},
+			OnError: func(err error) {
+				reported = err
+			},
+		}
+		input := "input"
+		buf := &bytes.Buffer{}
+		stream := jsoniter.NewStream(jsoniter.ConfigFastest, buf, 100)
+		subject.Encode(unsafe.Pointer(&input), stream)
+		_ = stream.Flush()
+		is.EqualError(reported, "a error occurred while serialization of 'map', error: 'failed'", "it should report the error")
+	})
 }
 
 func TestMap_IsEmpty(t *testing.T) {
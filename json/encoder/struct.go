@@ -3,7 +3,6 @@ package encoder
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"reflect"
 	"strings"
 	"unsafe"
@@ -14,6 +13,7 @@ import (
 type Struct struct {
 	Type     reflect.Type
 	Strategy func(string) string
+	OnError  func(error)
 }
 
 func (changer *Struct) IsEmpty(ptr unsafe.Pointer) bool {
@@ -25,7 +25,7 @@ func (changer *Struct) Encode(ptr unsafe.Pointer, stream *jsoniter.Stream) {
 	defer func() {
 		err := recover()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "a error occurred while serialization of '%v', error: '%v'", changer.Type.Name(), err)
+			report(changer.OnError, fmt.Errorf("a error occurred while serialization of '%v', error: '%v'", changer.Type.Name(), err))
 			stream.SetBuffer(beforeBuffer)
 		}
 	}()
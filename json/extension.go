@@ -11,12 +11,14 @@ import (
 type CaseStrategyExtension struct {
 	jsoniter.DummyExtension
 	Strategy func(string) string
+	OnError  func(error)
 }
 
 func (cs *CaseStrategyExtension) CreateMapKeyEncoder(typ reflect2.Type) jsoniter.ValEncoder {
 	if typ.Kind() == reflect.String {
 		return &encoder.Map{
 			Strategy: cs.Strategy,
+			OnError:  cs.OnError,
 		}
 	}
 	return nil
@@ -28,6 +30,7 @@ func (cs *CaseStrategyExtension) CreateEncoder(typ reflect2.Type) jsoniter.ValEn
 		return &encoder.Struct{
 			Type:     ty,
 			Strategy: cs.Strategy,
+			OnError:  cs.OnError,
 		}
 	}
 	return nil
@@ -11,6 +11,10 @@ func New() jsoniter.API {
 }
 
 func NewWithCaseStrategy(strategy func(string) string) jsoniter.API {
+	return NewWithErrorHandler(strategy, nil)
+}
+
+func NewWithErrorHandler(strategy func(string) string, onError func(error)) jsoniter.API {
 	json := jsoniter.Config{
 		EscapeHTML:                    false,
 		MarshalFloatWith6Digits:       false,
@@ -18,6 +22,7 @@ func NewWithCaseStrategy(strategy func(string) string) jsoniter.API {
 	}.Froze()
 	json.RegisterExtension(&CaseStrategyExtension{
 		Strategy: strategy,
+		OnError:  onError,
 	})
 	return json
 }
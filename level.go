@@ -1,6 +1,10 @@
 package splunk
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/mralves/tracer"
 )
 
@@ -29,3 +33,27 @@ func Level(level uint8) string {
 		return Verbose
 	}
 }
+
+var levelAliases = map[string]uint8{
+	"emergency":   tracer.Fatal,
+	"warn":        tracer.Warning,
+	"info":        tracer.Informational,
+	"information": tracer.Informational,
+	"verbose":     tracer.Debug,
+}
+
+func ParseLevel(name string) (uint8, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for level, known := range tracer.LevelNames {
+		if strings.ToLower(known) == name {
+			return uint8(level), nil
+		}
+	}
+	if level, ok := levelAliases[name]; ok {
+		return level, nil
+	}
+	if level, err := strconv.ParseUint(name, 10, 8); err == nil && uint8(level) <= tracer.Debug {
+		return uint8(level), nil
+	}
+	return 0, fmt.Errorf("unknown level %q", name)
+}
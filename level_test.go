@@ -26,3 +26,31 @@ func TestLevel(t *testing.T) {
 		is.Equal(expected, actual, "it should return the expected value")
 	}
 }
+
+func TestParseLevel(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	cases := map[string]uint8{
+		"debug":         tracer.Debug,
+		"Information":   tracer.Informational,
+		"INFO":          tracer.Informational,
+		"informational": tracer.Informational,
+		"notice":        tracer.Notice,
+		" warning ":     tracer.Warning,
+		"warn":          tracer.Warning,
+		"Error":         tracer.Error,
+		"critical":      tracer.Critical,
+		"alert":         tracer.Alert,
+		"fatal":         tracer.Fatal,
+		"3":             tracer.Error,
+	}
+	for input, expected := range cases {
+		actual, err := ParseLevel(input)
+		is.Nil(err, "it should return no error")
+		is.Equal(expected, actual, "it should return the level of %v", input)
+	}
+	for _, input := range []string{"", "loud", "8", "-1"} {
+		_, err := ParseLevel(input)
+		is.NotNil(err, "it should return an error for %v", input)
+	}
+}
@@ -0,0 +1,60 @@
+package splunk
+
+import (
+	"strings"
+	"sync"
+)
+
+type override struct {
+	level uint8
+	found bool
+}
+
+type levels struct {
+	overrides map[string]uint8
+	cache     sync.Map
+}
+
+func newLevels(overrides map[string]uint8) *levels {
+	if len(overrides) == 0 {
+		return nil
+	}
+	l := &levels{overrides: make(map[string]uint8, len(overrides))}
+	for prefix, level := range overrides {
+		l.overrides[strings.Trim(prefix, ".")] = level
+	}
+	return l
+}
+
+func (l *levels) resolve(owner string, minimum uint8) uint8 {
+	if l == nil {
+		return minimum
+	}
+	if cached, ok := l.cache.Load(owner); ok {
+		o := cached.(override)
+		if o.found {
+			return o.level
+		}
+		return minimum
+	}
+	o := l.match(owner)
+	l.cache.Store(owner, o)
+	if o.found {
+		return o.level
+	}
+	return minimum
+}
+
+func (l *levels) match(owner string) override {
+	name := owner
+	for {
+		if level, ok := l.overrides[name]; ok {
+			return override{level: level, found: true}
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			return override{}
+		}
+		name = name[:i]
+	}
+}
@@ -0,0 +1,68 @@
+package splunk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mralves/tracer"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLevels_Resolve(t *testing.T) {
+	t.Parallel()
+	subject := newLevels(map[string]uint8{
+		"payments.gateway":       tracer.Debug,
+		"payments.gateway.http.": tracer.Warning,
+		"orders":                 tracer.Error,
+	})
+	cases := map[string]uint8{
+		"payments.gateway":          tracer.Debug,
+		"payments.gateway.client":   tracer.Debug,
+		"payments.gateway.http":     tracer.Warning,
+		"payments.gateway.http.out": tracer.Warning,
+		"payments.gatewayx":         tracer.Informational,
+		"payments":                  tracer.Informational,
+		"orders.api":                tracer.Error,
+		"":                          tracer.Informational,
+	}
+	for owner, expected := range cases {
+		owner, expected := owner, expected
+		t.Run("when the owner is '"+owner+"'", func(t *testing.T) {
+			t.Parallel()
+			is := assert.New(t)
+			for i := 0; i < 2; i++ {
+				is.Equal(expected, subject.resolve(owner, tracer.Informational), "it should return the level of the longest prefix")
+			}
+		})
+	}
+	t.Run("when there are no overrides", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := newLevels(nil)
+		is.Nil(subject, "it should not create the overrides")
+		is.Equal(uint8(tracer.Error), subject.resolve("payments", tracer.Error), "it should return the minimum level")
+	})
+}
+
+func TestWriter_Write_Levels(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	buf := &buffer.Mock{}
+	buf.On("Write", mock.Anything).Return()
+	buf.On("Stats").Return(buffer.Stats{})
+	subject := &Writer{
+		buffer: buf,
+	}
+	subject.SetMinimumLevel(tracer.Informational)
+	subject.SetLevels(map[string]uint8{
+		"payments.gateway": tracer.Debug,
+	})
+	subject.Write(tracer.Entry{Level: tracer.Debug, Owner: "payments.gateway.http", Message: "Sent"})
+	subject.Write(tracer.Entry{Level: tracer.Debug, Owner: "orders", Message: "Created"})
+	subject.Write(tracer.Entry{Level: tracer.Informational, Owner: "orders", Message: "Created"})
+	_ = subject.writing.Wait(context.Background())
+	is.Equal(uint64(1), subject.Stats().Filtered, "it should filter by the level of the logger")
+	buf.AssertNumberOfCalls(t, "Write", 2)
+}
@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	splunk "github.com/mundipagg/tracer-splunk-writer"
+)
+
+const DefaultNamespace = "splunk_writer"
+
+type Source interface {
+	Stats() splunk.Stats
+	Healthy() bool
+}
+
+type Metrics struct {
+	sync.Mutex
+	namespace string
+	sources   []Source
+	batchSize *histogram
+	latency   *histogram
+}
+
+func New(namespace string) *Metrics {
+	if len(namespace) == 0 {
+		namespace = DefaultNamespace
+	}
+	return &Metrics{
+		namespace: namespace,
+		batchSize: newHistogram(BatchSizeBuckets),
+		latency:   newHistogram(LatencyBuckets),
+	}
+}
+
+func (m *Metrics) Register(source Source) {
+	m.Lock()
+	defer m.Unlock()
+	m.sources = append(m.sources, source)
+}
+
+func (m *Metrics) ObserveBatch(events int, bytes int, latency time.Duration, err error) {
+	m.batchSize.observe(float64(events))
+	m.latency.observe(latency.Seconds())
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	stats, healthy := m.collect()
+
+	m.counter(&buf, "events_received_total", "Entries received by the writer.", stats.Received)
+	m.counter(&buf, "events_filtered_total", "Entries filtered by level.", stats.Filtered)
+	m.counter(&buf, "events_sent_total", "Events sent to Splunk.", stats.Events)
+	m.counter(&buf, "batches_sent_total", "Batches sent to Splunk.", stats.Batches)
+	m.counter(&buf, "bytes_sent_total", "Bytes sent to Splunk.", stats.Bytes)
+
+	name := m.name("send_failures_total")
+	header(&buf, name, "Requests to Splunk that failed, by HTTP status (0 for transport errors).", "counter")
+	statuses := make([]int, 0, len(stats.Failures))
+	for status := range stats.Failures {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(&buf, "%s{status=\"%d\"} %d\n", name, status, stats.Failures[status])
+	}
+
+	m.reasons(&buf, "retries_total", "Retries of batches that failed to reach Splunk, by reason.", stats.RetriedBy)
+	m.reasons(&buf, "dropped_total", "Events dropped, by reason.", stats.DroppedBy)
+
+	m.gauge(&buf, "queue_depth", "Entries and events waiting to be sent.", float64(stats.QueueDepth))
+	m.gauge(&buf, "healthy", "Whether every writer is healthy.", healthy)
+	m.batchSize.write(&buf, m.name("batch_size"), "Number of events per request to Splunk.")
+	m.latency.write(&buf, m.name("hec_latency_seconds"), "Latency of the requests to Splunk.")
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+func (m *Metrics) collect() (splunk.Stats, float64) {
+	m.Lock()
+	sources := append([]Source(nil), m.sources...)
+	m.Unlock()
+
+	total := splunk.Stats{
+		Failures:  map[int]uint64{},
+		RetriedBy: map[string]uint64{},
+		DroppedBy: map[string]uint64{},
+	}
+	healthy := 1.0
+	for _, source := range sources {
+		stats := source.Stats()
+		total.Received += stats.Received
+		total.Filtered += stats.Filtered
+		total.Batches += stats.Batches
+		total.Events += stats.Events
+		total.Bytes += stats.Bytes
+		total.Retries += stats.Retries
+		total.Dropped += stats.Dropped
+		total.QueueDepth += stats.QueueDepth
+		for status, count := range stats.Failures {
+			total.Failures[status] += count
+		}
+		for reason, count := range stats.RetriedBy {
+			total.RetriedBy[reason] += count
+		}
+		for reason, count := range stats.DroppedBy {
+			total.DroppedBy[reason] += count
+		}
+		if !source.Healthy() {
+			healthy = 0
+		}
+	}
+	return total, healthy
+}
+
+func (m *Metrics) name(name string) string {
+	return m.namespace + "_" + name
+}
+
+func (m *Metrics) counter(w io.Writer, name string, help string, value uint64) {
+	name = m.name(name)
+	header(w, name, help, "counter")
+	fmt.Fprintf(w, "%s %d\n", name, value)
+}
+
+func (m *Metrics) reasons(w io.Writer, name string, help string, values map[string]uint64) {
+	name = m.name(name)
+	header(w, name, help, "counter")
+	reasons := make([]string, 0, len(values))
+	for reason := range values {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(w, "%s{reason=%q} %d\n", name, reason, values[reason])
+	}
+}
+
+func (m *Metrics) gauge(w io.Writer, name string, help string, value float64) {
+	name = m.name(name)
+	header(w, name, help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", name, format(value))
+}
+
+func header(w io.Writer, name string, help string, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func format(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	splunk "github.com/mundipagg/tracer-splunk-writer"
+	"github.com/stretchr/testify/assert"
+)
+
+type source struct {
+	stats   splunk.Stats
+	healthy bool
+}
+
+func (s *source) Stats() splunk.Stats {
+	return s.stats
+}
+
+func (s *source) Healthy() bool {
+	return s.healthy
+}
+
+func TestMetrics_WriteTo(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	subject := New("")
+	subject.Register(&source{
+		stats: splunk.Stats{
+			Received:   10,
+			Filtered:   2,
+			Batches:    3,
+			Events:     7,
+			Bytes:      700,
+			Failures:   map[int]uint64{503: 2, 0: 1},
+			Retries:    3,
+			RetriedBy:  map[string]uint64{"status_503": 2, "circuit_open": 1},
+			Dropped:    1,
+			DroppedBy:  map[string]uint64{splunk.DroppedByOverflow: 1},
+			QueueDepth: 4,
+		},
+		healthy: true,
+	})
+	subject.Register(&source{
+		stats: splunk.Stats{
+			Received:   5,
+			QueueDepth: 1,
+		},
+		healthy: true,
+	})
+	subject.ObserveBatch(7, 700, 30*time.Millisecond, nil)
+	subject.ObserveBatch(200, 20000, 2*time.Second, nil)
+
+	var actual strings.Builder
+	_, err := subject.WriteTo(&actual)
+	is.Nil(err, "it should return no error")
+	expected := []string{
+		"# HELP splunk_writer_events_received_total Entries received by the writer.",
+		"# TYPE splunk_writer_events_received_total counter",
+		"splunk_writer_events_received_total 15",
+		"splunk_writer_events_filtered_total 2",
+		"splunk_writer_events_sent_total 7",
+		"splunk_writer_batches_sent_total 3",
+		"splunk_writer_bytes_sent_total 700",
+		"# TYPE splunk_writer_retries_total counter",
+		`splunk_writer_retries_total{reason="circuit_open"} 1`,
+		`splunk_writer_retries_total{reason="status_503"} 2`,
+		"# TYPE splunk_writer_send_failures_total counter",
+		`splunk_writer_send_failures_total{status="0"} 1`,
+		`splunk_writer_send_failures_total{status="503"} 2`,
+		`splunk_writer_dropped_total{reason="overflow"} 1`,
+		"# TYPE splunk_writer_queue_depth gauge",
+		"splunk_writer_queue_depth 5",
+		"splunk_writer_healthy 1",
+		"# TYPE splunk_writer_batch_size histogram",
+		`splunk_writer_batch_size_bucket{le="5"} 0`,
+		`splunk_writer_batch_size_bucket{le="10"} 1`,
+		`splunk_writer_batch_size_bucket{le="250"} 2`,
+		`splunk_writer_batch_size_bucket{le="+Inf"} 2`,
+		"splunk_writer_batch_size_sum 207",
+		"splunk_writer_batch_size_count 2",
+		`splunk_writer_hec_latency_seconds_bucket{le="0.025"} 0`,
+		`splunk_writer_hec_latency_seconds_bucket{le="0.05"} 1`,
+		`splunk_writer_hec_latency_seconds_bucket{le="2.5"} 2`,
+		"splunk_writer_hec_latency_seconds_count 2",
+	}
+	for _, line := range expected {
+		is.Contains(actual.String(), line+"\n", "it should export the expected line")
+	}
+}
+
+func TestMetrics_ServeHTTP(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	subject := New("app")
+	subject.Register(&source{healthy: false})
+	recorder := httptest.NewRecorder()
+	subject.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	is.Equal(http.StatusOK, recorder.Code, "it should return ok")
+	is.Equal("text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"), "it should return the text exposition format")
+	is.Contains(recorder.Body.String(), "app_healthy 0\n", "it should use the namespace")
+}
@@ -0,0 +1,205 @@
+package splunk
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mralves/tracer"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+)
+
+type Option func(*Config)
+
+func NewWriter(address string, opts ...Option) (*Writer, error) {
+	config := Config{
+		Address:      address,
+		MinimumLevel: tracer.Debug,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return New(config), nil
+}
+
+func WithKey(key string) Option {
+	return func(c *Config) {
+		c.Key = key
+	}
+}
+
+func WithApplication(application string) Option {
+	return func(c *Config) {
+		c.Application = application
+	}
+}
+
+func WithApplicationKey(applicationKey string) Option {
+	return func(c *Config) {
+		c.ApplicationKey = applicationKey
+	}
+}
+
+func WithApplicationAsSource(applicationAsSource bool) Option {
+	return func(c *Config) {
+		c.ApplicationAsSource = applicationAsSource
+	}
+}
+
+func WithAddresses(addresses ...string) Option {
+	return func(c *Config) {
+		c.Addresses = append(c.Addresses, addresses...)
+	}
+}
+
+func WithBalancing(balancing Balancing) Option {
+	return func(c *Config) {
+		c.Balancing = balancing
+	}
+}
+
+func WithBuffer(config buffer.Config) Option {
+	return func(c *Config) {
+		c.Buffer = config
+	}
+}
+
+func WithMinimumLevel(minimumLevel uint8) Option {
+	return func(c *Config) {
+		c.MinimumLevel = minimumLevel
+	}
+}
+
+func WithLevels(levels map[string]uint8) Option {
+	return func(c *Config) {
+		c.Levels = levels
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.Timeout = timeout
+	}
+}
+
+func WithConfigLineLog(configLineLog Entry) Option {
+	return func(c *Config) {
+		c.ConfigLineLog = configLineLog
+	}
+}
+
+func WithDefaultPropertiesSplunk(defaultPropertiesSplunk Entry) Option {
+	return func(c *Config) {
+		c.DefaultPropertiesSplunk = defaultPropertiesSplunk
+	}
+}
+
+func WithDefaultPropertiesApp(defaultPropertiesApp Entry) Option {
+	return func(c *Config) {
+		c.DefaultPropertiesApp = defaultPropertiesApp
+	}
+}
+
+func WithMessageEnvelop(messageEnvelop string) Option {
+	return func(c *Config) {
+		c.MessageEnvelop = messageEnvelop
+	}
+}
+
+func WithTimestampFormat(timestampFormat TimestampFormat) Option {
+	return func(c *Config) {
+		c.TimestampFormat = timestampFormat
+	}
+}
+
+func WithEnrichment(enrichment Enrichment) Option {
+	return func(c *Config) {
+		c.Enrichment = enrichment
+	}
+}
+
+func WithQueue(queue QueueConfig) Option {
+	return func(c *Config) {
+		c.Queue = queue
+	}
+}
+
+func WithObserver(observer Observer) Option {
+	return func(c *Config) {
+		c.Observer = observer
+	}
+}
+
+func WithErrorHandler(errorHandler func(ErrorEvent)) Option {
+	return func(c *Config) {
+		c.ErrorHandler = errorHandler
+	}
+}
+
+func WithCompression(compression Compression) Option {
+	return func(c *Config) {
+		c.Compression = compression
+	}
+}
+
+func WithOversized(oversized OversizedPolicy) Option {
+	return func(c *Config) {
+		c.Oversized = oversized
+	}
+}
+
+func WithEncoding(encoding Encoding) Option {
+	return func(c *Config) {
+		c.Encoding = encoding
+	}
+}
+
+func WithRaw(raw Raw) Option {
+	return func(c *Config) {
+		c.Raw = raw
+	}
+}
+
+func WithAck(ack Ack) Option {
+	return func(c *Config) {
+		c.Ack = ack
+	}
+}
+
+func WithCircuitBreaker(circuitBreaker CircuitBreaker) Option {
+	return func(c *Config) {
+		c.CircuitBreaker = circuitBreaker
+	}
+}
+
+func WithTLS(tls TLS) Option {
+	return func(c *Config) {
+		c.TLS = tls
+	}
+}
+
+func WithTransport(transport Transport) Option {
+	return func(c *Config) {
+		c.Transport = transport
+	}
+}
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Config) {
+		c.HTTPClient = client
+	}
+}
+
+func WithReload(reload Reload) Option {
+	return func(c *Config) {
+		c.Reload = reload
+	}
+}
+
+func WithRedaction(rules ...RedactionRule) Option {
+	return func(c *Config) {
+		c.Redaction = append(c.Redaction, rules...)
+	}
+}
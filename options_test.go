@@ -0,0 +1,112 @@
+package splunk
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mralves/tracer"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	client := &http.Client{}
+	observer := &observer{}
+	actual := Config{}
+	for _, opt := range []Option{
+		WithKey("key"),
+		WithApplication("app"),
+		WithApplicationKey("Service"),
+		WithApplicationAsSource(true),
+		WithAddresses("http://a", "http://b"),
+		WithBalancing(Balancing{Selection: LeastFailures}),
+		WithBuffer(buffer.Config{Cap: 10}),
+		WithMinimumLevel(tracer.Warning),
+		WithLevels(map[string]uint8{"payments": tracer.Debug}),
+		WithTimeout(time.Second),
+		WithConfigLineLog(Entry{"index": "main"}),
+		WithDefaultPropertiesSplunk(Entry{"Team": "payments"}),
+		WithDefaultPropertiesApp(Entry{"Version": "1.0"}),
+		WithMessageEnvelop("[{Level}] %v"),
+		WithTimestampFormat(RFC3339),
+		WithEnrichment(Enrichment{Caller: true}),
+		WithQueue(QueueConfig{Capacity: 5}),
+		WithObserver(observer),
+		WithCompression(Compression{Gzip: true}),
+		WithOversized(TruncateOversized),
+		WithEncoding(JSONArray),
+		WithRaw(Raw{Enabled: true}),
+		WithAck(Ack{Enabled: true}),
+		WithCircuitBreaker(CircuitBreaker{Threshold: 3}),
+		WithTLS(TLS{InsecureSkipVerify: true}),
+		WithTransport(Transport{MaxConnsPerHost: 4}),
+		WithHTTPClient(client),
+		WithReload(Reload{File: "splunk.yaml"}),
+		WithRedaction(RedactionRule{Keys: []string{"cvv"}}),
+	} {
+		opt(&actual)
+	}
+	is.Equal(Config{
+		Key:                     "key",
+		Application:             "app",
+		ApplicationKey:          "Service",
+		ApplicationAsSource:     true,
+		Addresses:               []string{"http://a", "http://b"},
+		Balancing:               Balancing{Selection: LeastFailures},
+		Buffer:                  buffer.Config{Cap: 10},
+		MinimumLevel:            tracer.Warning,
+		Levels:                  map[string]uint8{"payments": tracer.Debug},
+		Timeout:                 time.Second,
+		ConfigLineLog:           Entry{"index": "main"},
+		DefaultPropertiesSplunk: Entry{"Team": "payments"},
+		DefaultPropertiesApp:    Entry{"Version": "1.0"},
+		MessageEnvelop:          "[{Level}] %v",
+		TimestampFormat:         RFC3339,
+		Enrichment:              Enrichment{Caller: true},
+		Queue:                   QueueConfig{Capacity: 5},
+		Observer:                observer,
+		Compression:             Compression{Gzip: true},
+		Oversized:               TruncateOversized,
+		Encoding:                JSONArray,
+		Raw:                     Raw{Enabled: true},
+		Ack:                     Ack{Enabled: true},
+		CircuitBreaker:          CircuitBreaker{Threshold: 3},
+		TLS:                     TLS{InsecureSkipVerify: true},
+		Transport:               Transport{MaxConnsPerHost: 4},
+		HTTPClient:              client,
+		Reload:                  Reload{File: "splunk.yaml"},
+		Redaction:               []RedactionRule{{Keys: []string{"cvv"}}},
+	}, actual, "it should set every field")
+	var handled []ErrorEvent
+	WithErrorHandler(func(e ErrorEvent) {
+		handled = append(handled, e)
+	})(&actual)
+	actual.ErrorHandler(ErrorEvent{Stage: buffer.StageSend})
+	is.Len(handled, 1, "it should set the error handler")
+}
+
+func TestNewWriter(t *testing.T) {
+	t.Parallel()
+	t.Run("when the config is valid", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject, err := NewWriter("http://localhost:8088/services/collector", WithApplication("app"))
+		is.Nil(err, "it should return no error")
+		if subject != nil {
+			is.Equal("http://localhost:8088/services/collector", subject.address, "it should use the address")
+			is.Equal(uint8(tracer.Debug), subject.current().minimumLevel, "it should log every level by default")
+			_, _ = subject.Close(context.Background())
+		}
+	})
+	t.Run("when the config is invalid", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject, err := NewWriter("localhost:8088", WithTimeout(-time.Second))
+		is.Nil(subject, "it should return no writer")
+		is.IsType(&ValidationError{}, err, "it should return a validation error")
+	})
+}
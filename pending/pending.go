@@ -0,0 +1,56 @@
+package pending
+
+import (
+	"context"
+	"sync"
+)
+
+type Counter struct {
+	sync.Mutex
+	count int
+	idle  chan struct{}
+}
+
+func (c *Counter) Add(n int) {
+	c.Lock()
+	defer c.Unlock()
+	if c.count == 0 {
+		c.idle = make(chan struct{})
+	}
+	c.count += n
+}
+
+func (c *Counter) Done(n int) {
+	c.Lock()
+	defer c.Unlock()
+	c.count -= n
+	if c.count <= 0 {
+		c.count = 0
+		if c.idle != nil {
+			close(c.idle)
+			c.idle = nil
+		}
+	}
+}
+
+func (c *Counter) Len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.count
+}
+
+func (c *Counter) Wait(ctx context.Context) error {
+	c.Lock()
+	if c.count == 0 {
+		c.Unlock()
+		return nil
+	}
+	idle := c.idle
+	c.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
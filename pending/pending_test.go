@@ -0,0 +1,45 @@
+package pending
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter_Wait(t *testing.T) {
+	t.Parallel()
+	t.Run("when there is nothing pending", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &Counter{}
+		is.Nil(subject.Wait(context.Background()), "it should return immediately")
+	})
+	t.Run("when everything pending is done before the deadline", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &Counter{}
+		subject.Add(2)
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			subject.Done(1)
+			subject.Done(1)
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		is.Nil(subject.Wait(ctx), "it should return no error")
+		is.Equal(0, subject.Len(), "it should have nothing pending")
+	})
+	t.Run("when the deadline is reached", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &Counter{}
+		subject.Add(3)
+		subject.Done(1)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		is.Equal(context.DeadlineExceeded, subject.Wait(ctx), "it should return the context error")
+		is.Equal(2, subject.Len(), "it should keep the pending count")
+	})
+}
@@ -0,0 +1,116 @@
+package splunk
+
+import (
+	"sync"
+
+	"github.com/mralves/tracer"
+)
+
+type OverflowPolicy uint8
+
+const (
+	DropNewest OverflowPolicy = iota
+	DropOldest
+	DropBelowLevel
+	Block
+)
+
+const (
+	DefaultQueueCapacity = 1000
+	DefaultQueueWorkers  = 1
+)
+
+type QueueConfig struct {
+	Capacity int
+	Workers  int
+	Overflow OverflowPolicy
+	Level    uint8
+}
+
+type queue struct {
+	QueueConfig
+	sync.Mutex
+	once    sync.Once
+	entries chan tracer.Entry
+	dropped map[string]uint64
+}
+
+func (sw *Writer) entries() chan tracer.Entry {
+	sw.queue.once.Do(func() {
+		if sw.queue.Capacity <= 0 {
+			sw.queue.Capacity = DefaultQueueCapacity
+		}
+		if sw.queue.Workers <= 0 {
+			sw.queue.Workers = DefaultQueueWorkers
+		}
+		sw.queue.entries = make(chan tracer.Entry, sw.queue.Capacity)
+		for i := 0; i < sw.queue.Workers; i++ {
+			go sw.worker(sw.queue.entries)
+		}
+	})
+	return sw.queue.entries
+}
+
+func (sw *Writer) worker(entries chan tracer.Entry) {
+	for entry := range entries {
+		sw.process(entry)
+		sw.writing.Done(1)
+	}
+}
+
+func (sw *Writer) enqueue(entry tracer.Entry) {
+	entries := sw.entries()
+	switch sw.queue.Overflow {
+	case Block:
+		entries <- entry
+	case DropBelowLevel:
+		if entry.Level > sw.queue.Level {
+			sw.tryEnqueue(entries, entry)
+		} else {
+			entries <- entry
+		}
+	case DropOldest:
+		for {
+			select {
+			case entries <- entry:
+				return
+			default:
+			}
+			select {
+			case old := <-entries:
+				sw.drop(old)
+			default:
+			}
+		}
+	default:
+		sw.tryEnqueue(entries, entry)
+	}
+}
+
+func (sw *Writer) tryEnqueue(entries chan tracer.Entry, entry tracer.Entry) {
+	select {
+	case entries <- entry:
+	default:
+		sw.drop(entry)
+	}
+}
+
+func (sw *Writer) drop(entry tracer.Entry) {
+	sw.queue.Lock()
+	if sw.queue.dropped == nil {
+		sw.queue.dropped = map[string]uint64{}
+	}
+	sw.queue.dropped[Level(entry.Level)]++
+	sw.queue.Unlock()
+	sw.writing.Done(1)
+}
+
+func (sw *Writer) Dropped() map[string]uint64 {
+	sw.queue.Lock()
+	defer sw.queue.Unlock()
+	dropped := make(map[string]uint64, len(sw.queue.dropped))
+	for level, count := range sw.queue.dropped {
+		dropped[level] = count
+	}
+	return dropped
+}
@@ -0,0 +1,114 @@
+package splunk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mralves/tracer"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWriter_Enqueue(t *testing.T) {
+	t.Parallel()
+	stalled := func(policy OverflowPolicy) (*Writer, chan string, chan struct{}) {
+		written := make(chan string, 10)
+		release := make(chan struct{})
+		buf := &buffer.Mock{}
+		buf.On("Write", mock.Anything).Run(func(args mock.Arguments) {
+			written <- args.Get(0).(Entry)["event"].(Entry)["Message"].(string)
+			<-release
+		}).Return()
+		subject := &Writer{
+			buffer: buf,
+			queue: queue{
+				QueueConfig: QueueConfig{
+					Capacity: 1,
+					Workers:  1,
+					Overflow: policy,
+					Level:    tracer.Error,
+				},
+			},
+		}
+		subject.SetMinimumLevel(tracer.Debug)
+		return subject, written, release
+	}
+	t.Run("when the policy is to drop the newest entry", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject, written, release := stalled(DropNewest)
+		subject.Write(tracer.Entry{Level: tracer.Error, Message: "First"})
+		is.Equal("First", <-written, "it should process the first entry")
+		subject.Write(tracer.Entry{Level: tracer.Error, Message: "Second"})
+		subject.Write(tracer.Entry{Level: tracer.Error, Message: "Third"})
+		close(release)
+		is.Equal("Second", <-written, "it should process the queued entry")
+		is.Equal(map[string]uint64{Error: 1}, subject.Dropped(), "it should count the dropped entry")
+	})
+	t.Run("when the policy is to drop the oldest entry", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject, written, release := stalled(DropOldest)
+		subject.Write(tracer.Entry{Level: tracer.Error, Message: "First"})
+		is.Equal("First", <-written, "it should process the first entry")
+		subject.Write(tracer.Entry{Level: tracer.Warning, Message: "Second"})
+		subject.Write(tracer.Entry{Level: tracer.Error, Message: "Third"})
+		close(release)
+		is.Equal("Third", <-written, "it should process the newest entry")
+		is.Equal(map[string]uint64{Warning: 1}, subject.Dropped(), "it should count the dropped entry")
+	})
+	t.Run("when the policy is to drop entries below a level", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject, written, release := stalled(DropBelowLevel)
+		subject.Write(tracer.Entry{Level: tracer.Error, Message: "First"})
+		is.Equal("First", <-written, "it should process the first entry")
+		subject.Write(tracer.Entry{Level: tracer.Error, Message: "Second"})
+		subject.Write(tracer.Entry{Level: tracer.Debug, Message: "Third"})
+		close(release)
+		is.Equal("Second", <-written, "it should process the queued entry")
+		is.Equal(map[string]uint64{Debug: 1}, subject.Dropped(), "it should count the dropped entry")
+	})
+	t.Run("when the policy is not set", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject, written, release := stalled(OverflowPolicy(0))
+		defer close(release)
+		subject.Write(tracer.Entry{Level: tracer.Error, Message: "First"})
+		is.Equal("First", <-written, "it should process the first entry")
+		done := make(chan struct{})
+		go func() {
+			subject.Write(tracer.Entry{Level: tracer.Error, Message: "Second"})
+			subject.Write(tracer.Entry{Level: tracer.Error, Message: "Third"})
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			is.Fail("it should not block the caller")
+		}
+		is.Equal(map[string]uint64{Error: 1}, subject.Dropped(), "it should drop the newest entry")
+	})
+	t.Run("when the policy is to block", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject, written, release := stalled(Block)
+		subject.Write(tracer.Entry{Level: tracer.Error, Message: "First"})
+		is.Equal("First", <-written, "it should process the first entry")
+		subject.Write(tracer.Entry{Level: tracer.Error, Message: "Second"})
+		done := make(chan struct{})
+		go func() {
+			subject.Write(tracer.Entry{Level: tracer.Error, Message: "Third"})
+			close(done)
+		}()
+		select {
+		case <-done:
+			is.Fail("it should wait for room in the queue")
+		case <-time.After(20 * time.Millisecond):
+		}
+		close(release)
+		<-done
+		is.Empty(subject.Dropped(), "it should drop nothing")
+	})
+}
@@ -0,0 +1,106 @@
+package splunk
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const ChannelHeader = "X-Splunk-Request-Channel"
+
+var rawParameters = []string{"host", "source", "sourcetype", "index"}
+
+type LineFormatter func(event Entry) string
+
+type renderedLine string
+
+type Raw struct {
+	Enabled   bool
+	Channel   string
+	Formatter LineFormatter
+}
+
+func KeyValueFormatter(event Entry) string {
+	var line strings.Builder
+	message, _ := event["Message"].(string)
+	line.WriteString(message)
+	properties, _ := event["AdditionalData"].(Entry)
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fmt.Sprint(properties[key])
+		if strings.ContainsAny(value, " =\"\n") {
+			value = strconv.Quote(value)
+		}
+		line.WriteString(" ")
+		line.WriteString(key)
+		line.WriteString("=")
+		line.WriteString(value)
+	}
+	return line.String()
+}
+
+func (sw *Writer) line(item interface{}) string {
+	if rendered, ok := item.(renderedLine); ok {
+		return string(rendered)
+	}
+	l, _ := item.(Entry)
+	event, _ := l["event"].(Entry)
+	return sw.formatter(event)
+}
+
+func (sw *Writer) marshalLine(item interface{}) ([]byte, error) {
+	return []byte(sw.line(item)), nil
+}
+
+func unmarshalLine(data []byte) (interface{}, error) {
+	return renderedLine(data), nil
+}
+
+func rawAddress(address string, lineLog Entry) string {
+	u, err := url.Parse(address)
+	if err != nil {
+		return address
+	}
+	query := u.Query()
+	for _, name := range rawParameters {
+		if value, ok := lineLog[name]; ok {
+			query.Set(name, fmt.Sprint(value))
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func newChannel() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
+func (sw *Writer) useRaw(config Config, addresses []string) {
+	sw.formatter = config.Raw.Formatter
+	if sw.formatter == nil {
+		sw.formatter = KeyValueFormatter
+	}
+	sw.channel = config.Raw.Channel
+	if len(sw.channel) == 0 {
+		sw.channel = newChannel()
+	}
+	sw.encoder.format = sw.line
+	lineLog := NewEntry(config.ConfigLineLog)
+	if _, ok := lineLog["source"]; !ok && config.ApplicationAsSource && len(config.Application) > 0 {
+		lineLog.Add("source", config.Application)
+	}
+	for i, address := range addresses {
+		addresses[i] = rawAddress(address, lineLog)
+	}
+}
@@ -0,0 +1,196 @@
+package splunk
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/icrowley/fake"
+	"github.com/jarcoal/httpmock"
+	"github.com/mralves/tracer"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+	"github.com/mundipagg/tracer-splunk-writer/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyValueFormatter(t *testing.T) {
+	t.Parallel()
+	t.Run("when the event has additional data", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual := KeyValueFormatter(Entry{
+			"Message":  "Payment approved",
+			"Severity": Information,
+			"AdditionalData": Entry{
+				"Value":  10.5,
+				"Name":   "John Doe",
+				"Amount": 3,
+				"Query":  "a=b",
+			},
+		})
+		is.Equal(`Payment approved Amount=3 Name="John Doe" Query="a=b" Value=10.5`, actual, "it should append the sorted properties")
+	})
+	t.Run("when the event has no additional data", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual := KeyValueFormatter(Entry{"Message": "Payment approved"})
+		is.Equal("Payment approved", actual, "it should return only the message")
+	})
+}
+
+func TestRawAddress(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	actual := rawAddress("http://localhost:8088/services/collector/raw?foo=bar", Entry{
+		"host":       "pod-1",
+		"index":      "main",
+		"source":     "api",
+		"sourcetype": "access_combined",
+		"other":      "ignored",
+	})
+	is.Equal("http://localhost:8088/services/collector/raw?foo=bar&host=pod-1&index=main&source=api&sourcetype=access_combined", actual, "it should move the envelope to the query")
+}
+
+func TestNewChannel(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	guid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	first, second := newChannel(), newChannel()
+	is.Regexp(guid, first, "it should return a GUID")
+	is.NotEqual(first, second, "it should return a new GUID every time")
+}
+
+func TestNew_Raw(t *testing.T) {
+	t.Parallel()
+	t.Run("when the channel is not given", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := New(Config{
+			Address:             "http://localhost:8088/services/collector/raw",
+			Application:         "app",
+			ApplicationAsSource: true,
+			ConfigLineLog:       Entry{"index": "main"},
+			Raw:                 Raw{Enabled: true},
+		})
+		is.Equal("http://localhost:8088/services/collector/raw?index=main&source=app", subject.address, "it should use the query parameters")
+		is.Len(subject.channel, 36, "it should generate a channel")
+		is.NotNil(subject.formatter, "it should use the default formatter")
+	})
+	t.Run("when the channel is given", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := New(Config{
+			Address: "http://localhost:8088/services/collector/raw",
+			Raw:     Raw{Enabled: true, Channel: "channel"},
+		})
+		is.Equal("channel", subject.channel, "it should use the given channel")
+	})
+}
+
+func TestWriter_Send_Raw(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	c := &http.Client{}
+	activateNonDefault(c)
+	url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+	var bodies []string
+	httpmock.RegisterResponder("POST", url, func(request *http.Request) (response *http.Response, err error) {
+		is.Equal("channel", request.Header.Get(ChannelHeader), "it should send the channel")
+		is.Equal("text/plain", request.Header.Get("Content-Type"), "it should send plain text")
+		body, _ := ioutil.ReadAll(request.Body)
+		bodies = append(bodies, string(body))
+		return httpmock.NewBytesResponse(200, nil), nil
+	})
+	subject := &Writer{
+		address:    url,
+		client:     c,
+		marshaller: json.New(),
+		channel:    "channel",
+		formatter: func(event Entry) string {
+			return event["Message"].(string)
+		},
+	}
+	subject.encoder.format = subject.line
+	err := subject.send([]interface{}{
+		Entry{"time": 1, "event": Entry{"Message": "first"}},
+		Entry{"time": 2, "event": Entry{"Message": "second"}},
+	})
+	is.Nil(err, "it should return no error")
+	is.Equal([]string{"first\nsecond"}, bodies, "it should send one line per event")
+}
+
+func TestWriter_Send_Panic(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	var reported []ErrorEvent
+	subject := &Writer{
+		marshaller: json.New(),
+		formatter: func(event Entry) string {
+			panic("no message")
+		},
+		errorHandler: func(e ErrorEvent) {
+			reported = append(reported, e)
+		},
+	}
+	subject.encoder.format = subject.line
+	err := subject.send([]interface{}{Entry{"event": Entry{}}})
+	is.EqualError(err, "no message", "it should return the panic as an error")
+	is.Equal([]ErrorEvent{{
+		Stage:     buffer.StageSend,
+		BatchSize: 1,
+		Err:       err,
+	}}, reported, "it should report the panic")
+	is.Equal(map[int]uint64{0: 1}, subject.stats.failures, "it should count the failure")
+}
+
+func TestNew_Raw_Spool(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	dir, _ := ioutil.TempDir("", "spool")
+	defer os.RemoveAll(dir)
+	bodies := make(chan string, 10)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(body)
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	subject := New(Config{
+		Address:      server.URL + "/services/collector/raw",
+		MinimumLevel: tracer.Debug,
+		Raw: Raw{
+			Enabled: true,
+			Formatter: func(event Entry) string {
+				return event["Message"].(string)
+			},
+		},
+		Buffer: buffer.Config{
+			Cap:        1,
+			Expiration: time.Minute,
+			BackOff:    time.Minute,
+			Spool: buffer.SpoolConfig{
+				Directory:      dir,
+				ReplayInterval: 10 * time.Millisecond,
+			},
+		},
+		ErrorHandler: func(ErrorEvent) {},
+	})
+	defer subject.Close(context.Background())
+	subject.Write(tracer.Entry{Level: tracer.Error, Message: "Payment refused"})
+	is.Equal("Payment refused", <-bodies, "it should send the line")
+	select {
+	case body := <-bodies:
+		is.Equal("Payment refused", body, "it should replay the spooled line")
+	case <-time.After(time.Second):
+		is.Fail("it should replay the spooled line")
+	}
+}
@@ -0,0 +1,247 @@
+package splunk
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const RedactionMask = "********"
+
+var errMissingHashKey = errors.New("the Hash strategy requires a HashKey")
+
+type RedactionStrategy uint8
+
+const (
+	MaskAll RedactionStrategy = iota
+	KeepLast4
+	Hash
+	Remove
+)
+
+type RedactionRule struct {
+	Keys     []string
+	Strategy RedactionStrategy
+	HashKey  string
+}
+
+type redactionRule struct {
+	matchers []func(string) bool
+	strategy RedactionStrategy
+	hashKey  []byte
+}
+
+type redactor struct {
+	rules   []redactionRule
+	marshal func(interface{}) ([]byte, error)
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+func newRedactor(rules []RedactionRule, marshaller jsoniter.API) (*redactor, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	r := &redactor{marshal: marshaller.Marshal}
+	for _, rule := range rules {
+		if rule.Strategy == Hash && len(rule.HashKey) == 0 {
+			return nil, errMissingHashKey
+		}
+		compiled := redactionRule{strategy: rule.Strategy, hashKey: []byte(rule.HashKey)}
+		for _, key := range rule.Keys {
+			matcher, err := keyMatcher(key)
+			if err != nil {
+				return nil, err
+			}
+			compiled.matchers = append(compiled.matchers, matcher)
+		}
+		r.rules = append(r.rules, compiled)
+	}
+	return r, nil
+}
+
+func keyMatcher(pattern string) (func(string) bool, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		expression, err := regexp.Compile("(?i)" + pattern[1:len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %v: %v", pattern, err)
+		}
+		return expression.MatchString, nil
+	}
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid redaction pattern %v: %v", pattern, err)
+	}
+	return func(key string) bool {
+		matched, _ := path.Match(pattern, strings.ToLower(key))
+		return matched
+	}, nil
+}
+
+func (r *redactor) match(keys []string) (redactionRule, bool) {
+	for _, rule := range r.rules {
+		for i := len(keys) - 1; i >= 0; i-- {
+			candidate := strings.Join(keys[i:], ".")
+			for _, matcher := range rule.matchers {
+				if matcher(candidate) {
+					return rule, true
+				}
+			}
+		}
+	}
+	return redactionRule{}, false
+}
+
+func (r *redactor) entry(properties Entry) Entry {
+	if r == nil {
+		return properties
+	}
+	redacted, _ := r.walk(reflect.ValueOf(properties), nil).(Entry)
+	return redacted
+}
+
+func (r *redactor) walk(value reflect.Value, keys []string) interface{} {
+	if !value.IsValid() {
+		return nil
+	}
+	t := value.Type()
+	if t.Implements(marshalerType) || t.Implements(textMarshalerType) || t.Implements(errorType) {
+		return r.marshaled(value, keys)
+	}
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return value.Interface()
+		}
+		return r.walk(value.Elem(), keys)
+	case reflect.Map:
+		redacted := Entry{}
+		for _, key := range value.MapKeys() {
+			r.field(redacted, fmt.Sprint(key.Interface()), value.MapIndex(key), keys)
+		}
+		return redacted
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return value.Interface()
+		}
+		redacted := make([]interface{}, value.Len())
+		for i := range redacted {
+			redacted[i] = r.walk(value.Index(i), keys)
+		}
+		return redacted
+	case reflect.Struct:
+		redacted := Entry{}
+		for i := 0; i < t.NumField(); i++ {
+			field := value.Field(i)
+			if !field.CanInterface() {
+				continue
+			}
+			name, ok := fieldName(t.Field(i), field)
+			if ok {
+				r.field(redacted, name, field, keys)
+			}
+		}
+		return redacted
+	}
+	return value.Interface()
+}
+
+// marshaled walks the encoding of the values that choose how they are marshaled,
+// they are kept as they are when it is not an object or an array.
+func (r *redactor) marshaled(value reflect.Value, keys []string) interface{} {
+	original := value.Interface()
+	data, err := r.marshal(original)
+	if err != nil {
+		return original
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var decoded interface{}
+	if decoder.Decode(&decoded) != nil {
+		return original
+	}
+	switch decoded.(type) {
+	case map[string]interface{}, []interface{}:
+		return r.walk(reflect.ValueOf(decoded), keys)
+	}
+	return original
+}
+
+func (r *redactor) field(redacted Entry, name string, value reflect.Value, keys []string) {
+	keys = append(keys[:len(keys):len(keys)], name)
+	rule, ok := r.match(keys)
+	if !ok {
+		redacted[name] = r.walk(value, keys)
+		return
+	}
+	if rule.strategy != Remove {
+		redacted[name] = redact(rule, value)
+	}
+}
+
+func fieldName(field reflect.StructField, value reflect.Value) (string, bool) {
+	tag := strings.Split(strings.TrimSpace(field.Tag.Get("json")), ",")
+	if tag[0] == "-" {
+		return "", false
+	}
+	if len(tag) > 1 && tag[1] == "omitempty" && isEmpty(value) {
+		return "", false
+	}
+	if len(tag[0]) > 0 {
+		return tag[0], true
+	}
+	return field.Name, true
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return value.IsNil()
+	}
+	return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+}
+
+func redact(rule redactionRule, value reflect.Value) interface{} {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return RedactionMask
+	}
+	text := fmt.Sprint(value.Interface())
+	switch rule.strategy {
+	case KeepLast4:
+		length := utf8.RuneCountInString(text)
+		if length <= 4 {
+			return RedactionMask
+		}
+		runes := []rune(text)
+		return strings.Repeat("*", length-4) + string(runes[length-4:])
+	case Hash:
+		mac := hmac.New(sha256.New, rule.hashKey)
+		mac.Write([]byte(text))
+		return hex.EncodeToString(mac.Sum(nil))
+	default:
+		return RedactionMask
+	}
+}
@@ -0,0 +1,150 @@
+package splunk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mralves/tracer"
+	"github.com/mundipagg/tracer-splunk-writer/json"
+	"github.com/stretchr/testify/assert"
+)
+
+type card struct {
+	Number string `json:"number"`
+	CVV    string
+	Holder string `json:"holder,omitempty"`
+	Secret string `json:"-"`
+}
+
+type token struct {
+	number string
+	cvv    string
+}
+
+func (t token) MarshalJSON() ([]byte, error) {
+	return []byte(`{"number":"` + t.number + `","cvv":"` + t.cvv + `"}`), nil
+}
+
+type payment struct {
+	Card    card
+	Amount  int
+	Created time.Time
+}
+
+func TestNewRedactor(t *testing.T) {
+	t.Parallel()
+	t.Run("when there are no rules", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject, err := newRedactor(nil, json.New())
+		is.Nil(err, "it should return no error")
+		is.Nil(subject, "it should return no redactor")
+		is.Equal(Entry{"cvv": "123"}, subject.entry(Entry{"cvv": "123"}), "it should keep the properties")
+	})
+	t.Run("when a pattern is invalid", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		_, err := newRedactor([]RedactionRule{{Keys: []string{"/[/"}}}, json.New())
+		is.NotNil(err, "it should reject an invalid regex")
+		_, err = newRedactor([]RedactionRule{{Keys: []string{"[card"}}}, json.New())
+		is.NotNil(err, "it should reject an invalid glob")
+		_, err = newRedactor([]RedactionRule{{Keys: []string{"document"}, Strategy: Hash}}, json.New())
+		is.Equal(errMissingHashKey, err, "it should reject a hash without a key")
+	})
+}
+
+func TestRedactor_Entry(t *testing.T) {
+	t.Parallel()
+	t.Run("when the keys match", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject, _ := newRedactor([]RedactionRule{
+			{Keys: []string{"*password*", "/^auth/"}},
+			{Keys: []string{"card.number"}, Strategy: KeepLast4},
+		}, json.New())
+		actual := subject.entry(Entry{
+			"UserPassword":  "secret",
+			"Authorization": "Bearer token",
+			"Number":        "4111111111111111",
+			"Card":          Entry{"Number": "4111111111111111"},
+		})
+		is.Equal(Entry{
+			"UserPassword":  RedactionMask,
+			"Authorization": RedactionMask,
+			"Number":        "4111111111111111",
+			"Card":          Entry{"Number": "************1111"},
+		}, actual, "it should match globs, regexes and dotted paths ignoring the case")
+	})
+	t.Run("when the strategies differ", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject, _ := newRedactor([]RedactionRule{
+			{Keys: []string{"mask"}, Strategy: MaskAll},
+			{Keys: []string{"last", "short"}, Strategy: KeepLast4},
+			{Keys: []string{"hash"}, Strategy: Hash, HashKey: "secret"},
+			{Keys: []string{"remove"}, Strategy: Remove},
+			{Keys: []string{"nested"}, Strategy: KeepLast4},
+		}, json.New())
+		actual := subject.entry(Entry{
+			"mask":   12345,
+			"last":   "123456",
+			"short":  "1234",
+			"hash":   "abc",
+			"remove": "gone",
+			"nested": Entry{"a": "123456"},
+		})
+		is.Equal(Entry{
+			"mask":   RedactionMask,
+			"last":   "**3456",
+			"short":  RedactionMask,
+			"hash":   "9946dad4e00e913fc8be8e5d3f7e110a4a9e832f83fb09c345285d78638d8a0e",
+			"nested": RedactionMask,
+		}, actual, "it should apply the strategy of the rule")
+	})
+	t.Run("when the values are nested", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		created := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+		subject, _ := newRedactor([]RedactionRule{
+			{Keys: []string{"cvv", "card.number", "created"}, Strategy: Remove},
+		}, json.New())
+		actual := subject.entry(Entry{
+			"Payments": []payment{{Card: card{Number: "4111", CVV: "123", Secret: "s"}, Amount: 10, Created: created}},
+			"Items":    map[int]interface{}{1: &card{CVV: "321", Holder: "Jane"}},
+			"At":       created,
+		})
+		is.Equal(Entry{
+			"Payments": []interface{}{Entry{"Card": Entry{}, "Amount": 10}},
+			"Items":    Entry{"1": Entry{"number": "", "holder": "Jane"}},
+			"At":       created,
+		}, actual, "it should walk maps, slices and structs named like their json encoding")
+	})
+	t.Run("when the values have their own marshaler", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		created := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+		subject, _ := newRedactor([]RedactionRule{{Keys: []string{"cvv"}}}, json.New())
+		actual := subject.entry(Entry{
+			"Token": token{number: "4111", cvv: "123"},
+			"At":    created,
+		})
+		is.Equal(Entry{
+			"Token": Entry{"number": "4111", "cvv": RedactionMask},
+			"At":    created,
+		}, actual, "it should walk their json encoding and keep the scalars")
+	})
+}
+
+func TestWriter_Write_Redaction(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	subject := &Writer{}
+	subject.redactor, _ = newRedactor([]RedactionRule{{Keys: []string{"cvv"}}}, json.New())
+	event := write(subject, tracer.Entry{
+		Level:   tracer.Error,
+		Message: "Card {Cvv} refused",
+		Args:    []interface{}{Entry{"Cvv": "123"}},
+	})["event"].(Entry)
+	is.Equal(Entry{"Cvv": RedactionMask}, event["AdditionalData"], "it should redact the properties")
+	is.Equal("Card "+RedactionMask+" refused", event["Message"], "it should format the message with the redacted properties")
+}
@@ -0,0 +1,123 @@
+package splunk
+
+import (
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+)
+
+const DefaultReloadInterval = 5 * time.Second
+
+type Reload struct {
+	File     string
+	Interval time.Duration
+}
+
+type settings struct {
+	minimumLevel            uint8
+	levels                  *levels
+	defaultPropertiesSplunk Entry
+	defaultPropertiesApp    Entry
+}
+
+func (sw *Writer) current() settings {
+	s, _ := sw.settings.Load().(settings)
+	return s
+}
+
+func (sw *Writer) update(change func(s *settings)) {
+	sw.reconfiguring.Lock()
+	defer sw.reconfiguring.Unlock()
+	s := sw.current()
+	change(&s)
+	sw.settings.Store(s)
+}
+
+func (sw *Writer) SetMinimumLevel(level uint8) {
+	sw.update(func(s *settings) {
+		s.minimumLevel = level
+	})
+}
+
+func (sw *Writer) SetLevels(levels map[string]uint8) {
+	sw.update(func(s *settings) {
+		s.levels = newLevels(levels)
+	})
+}
+
+func (sw *Writer) SetDefaultProperties(splunk Entry, app Entry) {
+	sw.update(func(s *settings) {
+		s.defaultPropertiesSplunk = splunk
+		s.defaultPropertiesApp = app
+	})
+}
+
+func (sw *Writer) SetBufferConfig(c buffer.Config) {
+	sw.buffer.Configure(c)
+}
+
+func (sw *Writer) Reload(path string) error {
+	tree, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	var loaded Config
+	if err := decode(path, "", tree, reflect.ValueOf(&loaded).Elem()); err != nil {
+		return err
+	}
+	has := func(name string) bool {
+		for key := range tree {
+			if simplify(key) == simplify(name) {
+				return true
+			}
+		}
+		return false
+	}
+	if has("MinimumLevel") {
+		sw.SetMinimumLevel(loaded.MinimumLevel)
+	}
+	if has("Levels") {
+		sw.SetLevels(loaded.Levels)
+	}
+	sw.update(func(s *settings) {
+		if has("DefaultPropertiesSplunk") {
+			s.defaultPropertiesSplunk = loaded.DefaultPropertiesSplunk
+		}
+		if has("DefaultPropertiesApp") {
+			s.defaultPropertiesApp = loaded.DefaultPropertiesApp
+		}
+	})
+	if has("Buffer") {
+		sw.SetBufferConfig(loaded.Buffer)
+	}
+	return nil
+}
+
+func (sw *Writer) watch(config Reload) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultReloadInterval
+	}
+	sw.reloading = make(chan struct{})
+	go func() {
+		last, _ := os.Stat(config.File)
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				info, err := os.Stat(config.File)
+				if err != nil || (last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size()) {
+					continue
+				}
+				last = info
+				if err := sw.Reload(config.File); err != nil {
+					sw.report(ErrorEvent{Stage: buffer.StageConfig, Err: err})
+				}
+			case <-sw.reloading:
+				return
+			}
+		}
+	}()
+}
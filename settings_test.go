@@ -0,0 +1,138 @@
+package splunk
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mralves/tracer"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWriter_Settings(t *testing.T) {
+	t.Parallel()
+	t.Run("when the settings change concurrently", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		subject := &Writer{}
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				subject.SetMinimumLevel(tracer.Warning)
+			}()
+			go func() {
+				defer wg.Done()
+				subject.SetDefaultProperties(Entry{"Team": "payments"}, nil)
+			}()
+		}
+		wg.Wait()
+		actual := subject.current()
+		is.Equal(uint8(tracer.Warning), actual.minimumLevel, "it should keep the minimum level")
+		is.Equal(Entry{"Team": "payments"}, actual.defaultPropertiesSplunk, "it should keep the default properties")
+	})
+	t.Run("when the minimum level changes", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		buf := &buffer.Mock{}
+		buf.On("Write", mock.Anything).Return()
+		buf.On("Stats").Return(buffer.Stats{})
+		subject := &Writer{
+			buffer: buf,
+		}
+		subject.SetMinimumLevel(tracer.Error)
+		subject.Write(tracer.Entry{Level: tracer.Debug, Message: "Message"})
+		subject.SetMinimumLevel(tracer.Debug)
+		subject.Write(tracer.Entry{Level: tracer.Debug, Message: "Message"})
+		_ = subject.writing.Wait(context.Background())
+		is.Equal(uint64(1), subject.Stats().Filtered, "it should filter with the level at the time of the write")
+		buf.AssertNumberOfCalls(t, "Write", 1)
+	})
+	t.Run("when the buffer config changes", func(t *testing.T) {
+		t.Parallel()
+		buf := &buffer.Mock{}
+		buf.On("Configure", buffer.Config{Cap: 10}).Return()
+		subject := &Writer{
+			buffer: buf,
+		}
+		subject.SetBufferConfig(buffer.Config{Cap: 10})
+		buf.AssertExpectations(t)
+	})
+}
+
+func TestWriter_Reload(t *testing.T) {
+	t.Parallel()
+	write := func(content string) string {
+		file, _ := ioutil.TempFile("", "splunk-*.json")
+		_, _ = file.WriteString(content)
+		_ = file.Close()
+		return file.Name()
+	}
+	t.Run("when the file has some settings", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		path := write(`{"minimumLevel":7,"Levels":{"payments":3},"DefaultPropertiesApp":{"Team":"payments"}}`)
+		defer os.Remove(path)
+		subject := &Writer{}
+		subject.SetDefaultProperties(Entry{"index": "main"}, nil)
+		is.Nil(subject.Reload(path), "it should return no error")
+		actual := subject.current()
+		is.Equal(uint8(tracer.Debug), actual.minimumLevel, "it should change the minimum level")
+		is.Equal(uint8(tracer.Error), actual.levels.resolve("payments.api", tracer.Debug), "it should change the levels")
+		is.Equal(Entry{"index": "main"}, actual.defaultPropertiesSplunk, "it should keep the settings not in the file")
+		is.Equal(Entry{"Team": "payments"}, actual.defaultPropertiesApp, "it should change the default properties")
+	})
+	t.Run("when the file has the buffer config", func(t *testing.T) {
+		t.Parallel()
+		buf := &buffer.Mock{}
+		buf.On("Configure", buffer.Config{Cap: 5, MaxBytes: 1024}).Return()
+		path := write(`{"Buffer":{"Cap":5,"MaxBytes":1024}}`)
+		defer os.Remove(path)
+		subject := &Writer{
+			buffer: buf,
+		}
+		_ = subject.Reload(path)
+		buf.AssertExpectations(t)
+	})
+	t.Run("when the file is invalid", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		path := write(`{"MinimumLevel":`)
+		defer os.Remove(path)
+		subject := &Writer{}
+		is.NotNil(subject.Reload(path), "it should return an error")
+		is.NotNil(subject.Reload(filepath.Join(os.TempDir(), "missing.json")), "it should return an error for a missing file")
+	})
+	t.Run("when the watched file changes", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		path := write(`{"MinimumLevel":3}`)
+		defer os.Remove(path)
+		subject := New(Config{
+			Address:      "http://localhost:8088/services/collector",
+			MinimumLevel: tracer.Error,
+			Reload: Reload{
+				File:     path,
+				Interval: 5 * time.Millisecond,
+			},
+		})
+		defer subject.Close(context.Background())
+		time.Sleep(20 * time.Millisecond)
+		is.Equal(uint8(tracer.Error), subject.current().minimumLevel, "it should not reload the file before it changes")
+		_ = ioutil.WriteFile(path, []byte(`{"MinimumLevel":7}`), 0600)
+		later := time.Now().Add(time.Second)
+		_ = os.Chtimes(path, later, later)
+		deadline := time.Now().Add(time.Second)
+		for subject.current().minimumLevel != tracer.Debug && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		is.Equal(uint8(tracer.Debug), subject.current().minimumLevel, "it should reload the file")
+	})
+}
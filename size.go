@@ -0,0 +1,56 @@
+package splunk
+
+import (
+	"unicode/utf8"
+)
+
+type OversizedPolicy uint8
+
+const (
+	RejectOversized OversizedPolicy = iota
+	TruncateOversized
+)
+
+const truncatedSuffix = "..."
+
+func (sw *Writer) size(item interface{}) int {
+	if sw.encoder.format != nil {
+		return len(sw.encoder.format(item))
+	}
+	body, err := sw.marshaller.Marshal(item)
+	if err != nil {
+		return 0
+	}
+	return len(body)
+}
+
+func (sw *Writer) truncate(item interface{}, max int) (interface{}, bool) {
+	line, ok := item.(Entry)
+	if !ok {
+		return item, false
+	}
+	event, ok := line["event"].(Entry)
+	if !ok {
+		return item, false
+	}
+	line = NewEntry(line)
+	event = NewEntry(event)
+	line["event"] = event
+	if _, ok := event["AdditionalData"]; ok {
+		event["AdditionalData"] = Entry{"Truncated": true}
+	}
+	size := sw.size(line)
+	if size <= max {
+		return line, true
+	}
+	message, _ := event["Message"].(string)
+	keep := len(message) - (size - max) - len(truncatedSuffix)
+	if keep <= 0 {
+		return item, false
+	}
+	for keep > 0 && !utf8.RuneStart(message[keep]) {
+		keep--
+	}
+	event["Message"] = message[:keep] + truncatedSuffix
+	return line, true
+}
@@ -0,0 +1,54 @@
+package splunk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mundipagg/tracer-splunk-writer/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter_Truncate(t *testing.T) {
+	t.Parallel()
+	subject := &Writer{
+		marshaller: json.New(),
+	}
+	line := func(message string) Entry {
+		return Entry{
+			"time": 1,
+			"event": Entry{
+				"Message": message,
+				"AdditionalData": Entry{
+					"Big": strings.Repeat("x", 100),
+				},
+			},
+		}
+	}
+	t.Run("when removing the additional data is enough", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		input := line("Message")
+		actual, ok := subject.truncate(input, 80)
+		is.True(ok, "it should truncate the event")
+		is.Equal(Entry{"Truncated": true}, actual.(Entry)["event"].(Entry)["AdditionalData"], "it should replace the additional data")
+		is.Equal("Message", actual.(Entry)["event"].(Entry)["Message"], "it should keep the message")
+		is.True(subject.size(actual) <= 80, "it should fit")
+		is.Len(input["event"].(Entry)["AdditionalData"], 1, "it should not change the original event")
+	})
+	t.Run("when the message must be cut", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual, ok := subject.truncate(line(strings.Repeat("é", 50)), 80)
+		is.True(ok, "it should truncate the event")
+		message := actual.(Entry)["event"].(Entry)["Message"].(string)
+		is.True(strings.HasSuffix(message, truncatedSuffix), "it should mark the message as truncated")
+		is.True(subject.size(actual) <= 80, "it should fit")
+		is.True(strings.HasPrefix(message, "éé"), "it should keep whole characters")
+	})
+	t.Run("when the event cannot fit", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		_, ok := subject.truncate(line("Message"), 10)
+		is.False(ok, "it should not truncate the event")
+	})
+}
@@ -2,11 +2,12 @@ package splunk
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
-	"os"
 	"regexp"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"strings"
@@ -15,141 +16,444 @@ import (
 	"github.com/mralves/tracer"
 	"github.com/mundipagg/tracer-splunk-writer/buffer"
 	"github.com/mundipagg/tracer-splunk-writer/json"
+	"github.com/mundipagg/tracer-splunk-writer/pending"
 	s "github.com/mundipagg/tracer-splunk-writer/strings"
 )
 
 type Writer struct {
 	sync.Locker
-	address                 string
-	key                     string
-	configLineLog           map[string]interface{}
-	defaultPropertiesSplunk map[string]interface{}
-	defaultPropertiesApp    map[string]interface{}
-	client                  *http.Client
-	buffer                  buffer.Buffer
-	minimumLevel            uint8
-	marshaller              jsoniter.API
-	messageEnvelop          string
+	address             string
+	key                 string
+	configLineLog       map[string]interface{}
+	client              *http.Client
+	buffer              buffer.Buffer
+	marshaller          jsoniter.API
+	messageEnvelop      string
+	application         string
+	applicationKey      string
+	applicationAsSource bool
+	timestampFormat     TimestampFormat
+	enrichment          Enrichment
+	writing             pending.Counter
+	closed              int32
+	queue               queue
+	stats               stats
+	observer            Observer
+	errorHandler        func(ErrorEvent)
+	compressor          compressor
+	encoder             encoder
+	channel             string
+	formatter           LineFormatter
+	acks                *acknowledger
+	endpoints           *endpoints
+	circuit             *circuit
+	redactor            *redactor
+	settings            atomic.Value
+	reconfiguring       sync.Mutex
+	reloading           chan struct{}
 }
 
+const DefaultApplicationKey = "Application"
+
+type ErrorEvent = buffer.ErrorEvent
+
 var punctuation = regexp.MustCompile(`(.+?)[?;:\\.,!]?$`)
 
-//Used when message contains properties to replace.
+// Used when message contains properties to replace.
 var r = strings.NewReplacer("{", "{{.", "}", "}}")
 
 func (sw *Writer) Write(entry tracer.Entry) {
-	go func(sw *Writer, entry tracer.Entry) {
-		defer func() {
-			if err := recover(); err != nil {
-				stderr("COULD NOT SEND SPLUNK TO SPLUNK BECAUSE %v", err)
-			}
-		}()
-		if entry.Level > sw.minimumLevel {
-			return
-		}
+	settings := sw.current()
+	filtered := entry.Level > settings.levels.resolve(entry.Owner, settings.minimumLevel)
+	sw.stats.receive(filtered)
+	if filtered {
+		return
+	}
+	sw.writing.Add(1)
+	if atomic.LoadInt32(&sw.closed) == 1 {
+		sw.writing.Done(1)
+		sw.stats.drop(DroppedByClosed, 1)
+		return
+	}
+	sw.enqueue(entry)
+}
 
-		extraProperties := map[string]interface{}{
-			"RequestKey": entry.TransactionId,
+func (sw *Writer) process(entry tracer.Entry) {
+	defer func() {
+		if err := recover(); err != nil {
+			sw.stats.drop(DroppedByProcess, 1)
+			sw.report(ErrorEvent{
+				Stage:   buffer.StageProcess,
+				Err:     fmt.Errorf("%v", err),
+				Dropped: 1,
+			})
 		}
+	}()
 
-		if s.IsBlank(entry.TransactionId) {
-			delete(extraProperties, "RequestKey")
-		}
+	extraProperties := map[string]interface{}{
+		"RequestKey": entry.TransactionId,
+	}
 
-		properties := NewEntry(append(entry.Args, sw.defaultPropertiesApp, extraProperties))
+	if s.IsBlank(entry.TransactionId) {
+		delete(extraProperties, "RequestKey")
+	}
 
-		message := punctuation.FindStringSubmatch(s.Capitalize(entry.Message))[1]
+	settings := sw.current()
+	properties := NewEntry(append(entry.Args, settings.defaultPropertiesApp, extraProperties, sw.enrichment.properties(entry)))
+	properties = sw.redactor.entry(properties)
 
-		message, err := s.ProcessString(r.Replace(message), properties)
-		if err != nil {
-			message = entry.Message
+	message := punctuation.FindStringSubmatch(s.Capitalize(entry.Message))[1]
+
+	message, err := s.ProcessString(r.Replace(message), properties)
+	if err != nil {
+		message = entry.Message
+	}
+	message = sw.envelop(message, entry)
+
+	at := entry.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	l := NewEntry(sw.configLineLog)
+	l.Add("time", timestamp(at))
+	if _, ok := l["source"]; !ok && sw.applicationAsSource && len(sw.application) > 0 {
+		l.Add("source", sw.application)
+	}
+	e := NewEntry(Entry{
+		"AdditionalData": properties,
+		"Message":        message,
+		"Severity":       Level(entry.Level),
+	}, settings.defaultPropertiesSplunk)
+	if len(sw.application) > 0 && len(sw.applicationKey) > 0 {
+		e.Add(sw.applicationKey, sw.application)
+	}
+	if value, ok := eventTimestamp(at, sw.timestampFormat); ok {
+		e.Add(TimestampKey, value)
+	}
+	l.Add("event", e)
+
+	sw.buffer.Write(l)
+}
+
+func (sw *Writer) envelop(message string, entry tracer.Entry) string {
+	if len(sw.messageEnvelop) == 0 {
+		return message
+	}
+	return strings.NewReplacer(
+		"%v", message,
+		"{Application}", sw.application,
+		"{Level}", Level(entry.Level),
+		"{Owner}", entry.Owner,
+	).Replace(sw.messageEnvelop)
+}
+
+func (sw *Writer) Flush(ctx context.Context) (int, error) {
+	if err := sw.writing.Wait(ctx); err != nil {
+		return sw.writing.Len(), err
+	}
+	dropped := 0
+	for {
+		n, err := sw.buffer.Flush(ctx)
+		dropped += n
+		if err != nil || sw.acks.unacked() == 0 {
+			return dropped, err
 		}
+		if err := sw.acks.pending.Wait(ctx); err != nil {
+			return dropped + sw.acks.unacked(), err
+		}
+	}
+}
 
-		l := NewEntry(sw.configLineLog)
-		l.Add("time", time.Now().UTC().UnixNano())
-		e := NewEntry(Entry{
-			"AdditionalData": properties,
-			"Message":        message,
-			"Severity":       Level(entry.Level),
-		}, sw.defaultPropertiesSplunk)
-		l.Add("event", e)
-
-		sw.buffer.Write(l)
-	}(sw, entry)
+func (sw *Writer) Close(ctx context.Context) (int, error) {
+	if !atomic.CompareAndSwapInt32(&sw.closed, 0, 1) {
+		return 0, nil
+	}
+	if sw.acks != nil {
+		defer sw.acks.stop()
+	}
+	if sw.endpoints != nil {
+		defer sw.endpoints.stop()
+	}
+	if sw.reloading != nil {
+		defer close(sw.reloading)
+	}
+	if err := sw.writing.Wait(ctx); err != nil {
+		dropped, _ := sw.buffer.Close(ctx)
+		return dropped + sw.writing.Len(), err
+	}
+	close(sw.entries())
+	if sw.acks != nil {
+		if dropped, err := sw.Flush(ctx); err != nil {
+			_, _ = sw.buffer.Close(ctx)
+			return dropped, err
+		}
+	}
+	return sw.buffer.Close(ctx)
 }
 
-func (sw *Writer) send(events []interface{}) error {
+func (sw *Writer) send(events []interface{}) (result error) {
 	defer func() {
 		err := recover()
 		if err != nil {
-			fmt.Printf("%v\n", err)
+			result = fmt.Errorf("%v", err)
+			sw.stats.failure(0, result)
+			sw.report(ErrorEvent{
+				Stage:     buffer.StageSend,
+				BatchSize: len(events),
+				Err:       result,
+			})
 		}
 	}()
 
-	body, err := sw.marshaller.Marshal(events)
+	encoded, err := sw.encoder.encode(sw.marshaller, events)
 	if err != nil {
-		stderr("COULD NOT SEND LOG TO SPLUNK BECAUSE %v", err)
+		sw.stats.failure(0, err)
+		sw.report(ErrorEvent{
+			Stage:     buffer.StageEncode,
+			BatchSize: len(events),
+			Err:       err,
+		})
 		return err
 	}
+	defer sw.encoder.release(encoded)
 
-	request, _ := http.NewRequest(http.MethodPost, sw.address, bytes.NewBuffer(body))
+	body := encoded.Bytes()
+	if sw.compressor.Gzip {
+		body, err = sw.compressor.compress(body)
+		if err != nil {
+			sw.stats.failure(0, err)
+			sw.report(ErrorEvent{
+				Stage:     buffer.StageEncode,
+				BatchSize: len(events),
+				Err:       err,
+			})
+			return err
+		}
+	}
+
+	address := sw.address
+	target := sw.endpoints.pick()
+	if target != nil {
+		address = target.address
+	}
+	request, _ := http.NewRequest(http.MethodPost, address, bytes.NewBuffer(body))
 	if len(sw.key) > 0 {
 		request.Header.Set("Authorization", "Splunk "+sw.key)
 	}
-	request.Header.Set("Content-Type", "application/json")
+	if len(sw.channel) > 0 {
+		request.Header.Set(ChannelHeader, sw.channel)
+	}
+	if sw.formatter != nil {
+		request.Header.Set("Content-Type", "text/plain")
+	} else {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if sw.compressor.Gzip {
+		request.Header.Set("Content-Encoding", "gzip")
+	}
 
 	var response *http.Response
+	started := time.Now()
 	response, err = sw.client.Do(request)
+	latency := time.Since(started)
+	if err != nil {
+		sw.observe(target, 0, err)
+	} else {
+		sw.observe(target, response.StatusCode, nil)
+	}
+	if sw.observer != nil {
+		defer func() {
+			sw.observer.ObserveBatch(len(events), len(body), latency, result)
+		}()
+	}
 	if err != nil {
-		stderr("COULD NOT SEND LOG TO SPLUNK BECAUSE %v", err)
+		sw.stats.failure(0, err)
+		sw.report(ErrorEvent{
+			Stage:     buffer.StageSend,
+			BatchSize: len(events),
+			Err:       err,
+		})
 		return err
 	}
-	response.Body.Close()
-	if response.StatusCode != 200 {
-		stderr("COULD NOT SEND LOG TO SPLUNK BECAUSE %v", response.Status)
-		return fmt.Errorf("request returned %v", response.StatusCode)
+	defer response.Body.Close()
+	if response.StatusCode == 200 {
+		if !sw.confirm(address, response, events, len(body)) {
+			sw.stats.success(len(events), len(body))
+		}
+		return nil
 	}
 
-	return nil
+	hecErr := newHECError(response)
+	sw.stats.failure(response.StatusCode, hecErr)
+	if hecErr.Category == Partial && hecErr.InvalidEvent < len(events) {
+		sw.report(ErrorEvent{
+			Stage:     buffer.StageSend,
+			BatchSize: len(events),
+			Status:    response.StatusCode,
+			Err:       hecErr,
+			Dropped:   1,
+		})
+		sw.stats.success(hecErr.InvalidEvent, 0)
+		sw.stats.drop(DroppedByInvalid, 1)
+		remaining := events[hecErr.InvalidEvent+1:]
+		if len(remaining) == 0 {
+			return nil
+		}
+		err = sw.send(remaining)
+		if _, partial := err.(*buffer.PartialError); err == nil || partial {
+			return err
+		}
+		return &buffer.PartialError{Err: err, Remaining: remaining}
+	}
+	sw.report(ErrorEvent{
+		Stage:     buffer.StageSend,
+		BatchSize: len(events),
+		Status:    response.StatusCode,
+		Err:       hecErr,
+	})
+	return hecErr
 }
 
-func stderr(message string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, message+"\n", args...)
+func (sw *Writer) report(e ErrorEvent) {
+	if sw.errorHandler == nil {
+		buffer.DefaultErrorHandler(e)
+		return
+	}
+	sw.errorHandler(e)
 }
 
 type Config struct {
 	Address                 string
+	Addresses               []string
+	Balancing               Balancing
 	Key                     string
 	Application             string
+	ApplicationKey          string
+	ApplicationAsSource     bool
 	Buffer                  buffer.Config
 	MinimumLevel            uint8
+	Levels                  map[string]uint8
 	Timeout                 time.Duration
 	ConfigLineLog           Entry
 	DefaultPropertiesSplunk Entry
 	DefaultPropertiesApp    Entry
 	MessageEnvelop          string
+	TimestampFormat         TimestampFormat
+	Enrichment              Enrichment
+	Queue                   QueueConfig
+	Observer                Observer
+	ErrorHandler            func(ErrorEvent)
+	Compression             Compression
+	Oversized               OversizedPolicy
+	Encoding                Encoding
+	Raw                     Raw
+	Ack                     Ack
+	CircuitBreaker          CircuitBreaker
+	TLS                     TLS
+	Transport               Transport
+	HTTPClient              *http.Client
+	Reload                  Reload
+	Redaction               []RedactionRule
+}
+
+func (config Config) addresses() []string {
+	var addresses []string
+	if len(config.Address) > 0 {
+		addresses = append(addresses, config.Address)
+	}
+	for _, address := range config.Addresses {
+		if len(address) > 0 && address != config.Address {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
 }
 
 func New(config Config) *Writer {
 	writer := Writer{
-		Locker:  &sync.RWMutex{},
-		address: config.Address,
-		key:     config.Key,
-		client: &http.Client{
-			Timeout: config.Timeout,
-			Transport: &http.Transport{
-				TLSHandshakeTimeout: config.Timeout,
-				IdleConnTimeout:     config.Timeout,
-			},
+		Locker:              &sync.RWMutex{},
+		address:             config.Address,
+		key:                 config.Key,
+		messageEnvelop:      config.MessageEnvelop,
+		application:         config.Application,
+		applicationKey:      config.ApplicationKey,
+		applicationAsSource: config.ApplicationAsSource,
+		timestampFormat:     config.TimestampFormat,
+		enrichment:          config.Enrichment,
+		observer:            config.Observer,
+		errorHandler:        config.ErrorHandler,
+		configLineLog:       config.ConfigLineLog,
+		queue: queue{
+			QueueConfig: config.Queue,
+		},
+		compressor: compressor{
+			Compression: config.Compression,
+		},
+		encoder: encoder{
+			Encoding: config.Encoding,
 		},
-		messageEnvelop:          config.MessageEnvelop,
+	}
+	writer.settings.Store(settings{
 		minimumLevel:            config.MinimumLevel,
-		configLineLog:           config.ConfigLineLog,
+		levels:                  newLevels(config.Levels),
 		defaultPropertiesSplunk: config.DefaultPropertiesSplunk,
 		defaultPropertiesApp:    config.DefaultPropertiesApp,
-		marshaller:              json.NewWithCaseStrategy(s.UseAnnotation),
+	})
+	if config.MinimumLevel == tracer.Fatal && len(config.Levels) == 0 {
+		writer.report(ErrorEvent{Stage: buffer.StageConfig, Err: ErrOnlyFatal})
+	}
+	client, err := newClient(config)
+	writer.client = client
+	if err != nil {
+		writer.report(ErrorEvent{Stage: buffer.StageConfig, Err: err})
+	}
+	writer.marshaller = json.NewWithErrorHandler(s.UseAnnotation, func(err error) {
+		writer.report(ErrorEvent{Stage: buffer.StageEncode, Err: err})
+	})
+	writer.redactor, err = newRedactor(config.Redaction, writer.marshaller)
+	if err != nil {
+		writer.report(ErrorEvent{Stage: buffer.StageConfig, Err: err})
+	}
+	addresses := config.addresses()
+	if config.Raw.Enabled {
+		writer.useRaw(config, addresses)
+	}
+	if len(addresses) > 0 {
+		writer.address = addresses[0]
+		writer.endpoints = newEndpoints(addresses, config.Balancing)
+	}
+	if config.Ack.Enabled {
+		if len(writer.channel) == 0 {
+			writer.channel = newChannel()
+		}
+		writer.acks = newAcknowledger(config.Ack)
+	}
+	if config.Buffer.ErrorHandler == nil {
+		config.Buffer.ErrorHandler = config.ErrorHandler
+	}
+	if len(writer.applicationKey) == 0 {
+		writer.applicationKey = DefaultApplicationKey
+	}
+	if config.CircuitBreaker.Threshold > 0 {
+		writer.circuit = newCircuit(config.CircuitBreaker)
+	}
+	config.Buffer.OnOverflow = writer.deliver
+	if config.Buffer.Size == nil {
+		config.Buffer.Size = writer.size
+	}
+	if config.Oversized == TruncateOversized && config.Buffer.Truncate == nil {
+		config.Buffer.Truncate = writer.truncate
+	}
+	if writer.formatter != nil && config.Buffer.Spool.Marshal == nil && config.Buffer.Spool.Unmarshal == nil {
+		config.Buffer.Spool.Marshal = writer.marshalLine
+		config.Buffer.Spool.Unmarshal = unmarshalLine
+	}
+	if config.Buffer.Spool.Marshal == nil {
+		config.Buffer.Spool.Marshal = writer.marshaller.Marshal
 	}
-	config.Buffer.OnOverflow = writer.send
 	writer.buffer = buffer.New(config.Buffer)
+	if len(config.Reload.File) > 0 {
+		writer.watch(config.Reload)
+	}
 	return &writer
 }
@@ -1,7 +1,9 @@
 package splunk
 
 import (
+	"context"
 	"errors"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"sync"
@@ -14,6 +16,7 @@ import (
 	"github.com/mundipagg/tracer-splunk-writer/buffer"
 	"github.com/mundipagg/tracer-splunk-writer/json"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type event struct {
@@ -23,6 +26,18 @@ type event struct {
 	Timestamp       string
 }
 
+func write(subject *Writer, entry tracer.Entry) Entry {
+	written := make(chan Entry, 1)
+	buf := &buffer.Mock{}
+	buf.On("Write", mock.Anything).Run(func(args mock.Arguments) {
+		written <- args.Get(0).(Entry)
+	}).Return()
+	subject.buffer = buf
+	subject.SetMinimumLevel(tracer.Debug)
+	subject.Write(entry)
+	return <-written
+}
+
 func TestWriter_Write(t *testing.T) {
 	os.Stderr, _ = os.Open(os.DevNull)
 	t.Parallel()
@@ -32,9 +47,9 @@ func TestWriter_Write(t *testing.T) {
 		ref := time.Now()
 		stackTrace := tracer.GetStackTrace(3)
 		subject := &Writer{
-			buffer:       buf,
-			minimumLevel: tracer.Error,
+			buffer: buf,
 		}
+		subject.SetMinimumLevel(tracer.Error)
 
 		entry := tracer.Entry{
 			Level:         tracer.Debug,
@@ -74,12 +89,12 @@ func TestWriter_Write(t *testing.T) {
 		buf.On("Write", event).Return()
 		subject := &Writer{
 			buffer:         buf,
-			minimumLevel:   tracer.Debug,
 			messageEnvelop: "Before %v After",
-			defaultPropertiesApp: Entry{
-				"Name": "Default",
-			},
 		}
+		subject.SetMinimumLevel(tracer.Debug)
+		subject.SetDefaultProperties(nil, Entry{
+			"Name": "Default",
+		})
 
 		entry := tracer.Entry{
 			Level:         tracer.Critical,
@@ -100,6 +115,170 @@ func TestWriter_Write(t *testing.T) {
 	})
 }
 
+func TestWriter_Write_MessageEnvelop(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"":                                    "Message with Value",
+		"Before %v After":                     "Before Message with Value After",
+		"[{Application}] {Level} {Owner}: %v": "[App] Error owner: Message with Value",
+		"{Unknown} %v":                        "{Unknown} Message with Value",
+	}
+	for envelop, expected := range cases {
+		envelop, expected := envelop, expected
+		t.Run("when the envelop is '"+envelop+"'", func(t *testing.T) {
+			t.Parallel()
+			is := assert.New(t)
+			subject := &Writer{
+				messageEnvelop: envelop,
+				application:    "App",
+			}
+			actual := write(subject, tracer.Entry{
+				Level:   tracer.Error,
+				Message: "message with {Name}.",
+				Owner:   "owner",
+				Args: []interface{}{
+					Entry{"Name": "Value"},
+				},
+			})["event"].(Entry)["Message"]
+			is.Equal(expected, actual, "it should wrap the message")
+		})
+	}
+}
+
+func TestWriter_Write_Application(t *testing.T) {
+	t.Parallel()
+	entry := tracer.Entry{
+		Level:   tracer.Error,
+		Message: "Message",
+	}
+	t.Run("when the application is not used as source", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual := write(&Writer{
+			application:    "App",
+			applicationKey: DefaultApplicationKey,
+		}, entry)
+		is.Equal("App", actual["event"].(Entry)["Application"], "it should add the application to the event")
+		is.NotContains(actual, "source", "it should not set the source")
+	})
+	t.Run("when the application is used as source", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual := write(&Writer{
+			application:         "App",
+			applicationKey:      "Service",
+			applicationAsSource: true,
+		}, entry)
+		is.Equal("App", actual["event"].(Entry)["Service"], "it should add the application to the event with the given key")
+		is.Equal("App", actual["source"], "it should set the source")
+	})
+	t.Run("when the source is already configured", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual := write(&Writer{
+			application:         "App",
+			applicationKey:      DefaultApplicationKey,
+			applicationAsSource: true,
+			configLineLog: Entry{
+				"source": "Source",
+			},
+		}, entry)
+		is.Equal("Source", actual["source"], "it should keep the configured source")
+	})
+}
+
+func TestWriter_Write_Timestamp(t *testing.T) {
+	t.Parallel()
+	entry := tracer.Entry{
+		Level:   tracer.Error,
+		Message: "Message",
+		Time:    time.Date(2019, 10, 2, 7, 6, 40, 123456789, time.UTC),
+	}
+	t.Run("when the format is epoch seconds", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual := write(&Writer{}, entry)
+		is.Equal(1570000000.123, actual["time"], "it should use the entry time in epoch seconds")
+		is.NotContains(actual["event"], TimestampKey, "it should not add the timestamp to the event")
+	})
+	t.Run("when the format is epoch milliseconds", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual := write(&Writer{timestampFormat: EpochMillis}, entry)
+		is.Equal(1570000000.123, actual["time"], "it should use the entry time in epoch seconds")
+		is.Equal(int64(1570000000123), actual["event"].(Entry)[TimestampKey], "it should add the milliseconds to the event")
+	})
+	t.Run("when the format is RFC3339", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		actual := write(&Writer{timestampFormat: RFC3339}, entry)
+		is.Equal(1570000000.123, actual["time"], "it should use the entry time in epoch seconds")
+		is.Equal("2019-10-02T07:06:40.123456789Z", actual["event"].(Entry)[TimestampKey], "it should add the timestamp to the event")
+	})
+}
+
+func TestWriter_Write_Enrichment(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	stackTrace := tracer.GetStackTrace(1)
+	subject := &Writer{
+		enrichment: Enrichment{
+			Caller: true,
+			Owner:  true,
+		},
+	}
+	actual := write(subject, tracer.Entry{
+		Level:      tracer.Error,
+		Message:    "Message",
+		Owner:      "owner",
+		StackTrace: stackTrace,
+	})["event"].(Entry)["AdditionalData"].(Entry)
+	is.Equal(stackTrace[0].String(), actual["Caller"], "it should add the caller")
+	is.Equal("owner", actual["Owner"], "it should add the owner")
+}
+
+func TestWriter_Close(t *testing.T) {
+	t.Parallel()
+	t.Run("when the buffer drains before the deadline", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		buf := &buffer.Mock{}
+		buf.On("Write", mock.Anything).Return()
+		buf.On("Close", mock.Anything).Return(0, nil)
+		subject := &Writer{
+			buffer: buf,
+		}
+		subject.SetMinimumLevel(tracer.Debug)
+		subject.Write(tracer.Entry{
+			Level:   tracer.Error,
+			Message: "Message",
+		})
+		dropped, err := subject.Close(context.Background())
+		is.Nil(err, "it should return no error")
+		is.Equal(0, dropped, "it should drop nothing")
+		buf.AssertNumberOfCalls(t, "Write", 1)
+		subject.Write(tracer.Entry{
+			Level:   tracer.Error,
+			Message: "Message",
+		})
+		time.Sleep(10 * time.Millisecond)
+		buf.AssertNumberOfCalls(t, "Write", 1)
+		buf.AssertNumberOfCalls(t, "Close", 1)
+	})
+	t.Run("when the buffer does not drain before the deadline", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		buf := &buffer.Mock{}
+		buf.On("Close", mock.Anything).Return(4, context.DeadlineExceeded)
+		subject := &Writer{
+			buffer: buf,
+		}
+		dropped, err := subject.Close(context.Background())
+		is.Equal(context.DeadlineExceeded, err, "it should return the buffer error")
+		is.Equal(4, dropped, "it should report the events dropped by the buffer")
+	})
+}
+
 func TestWriter_Send(t *testing.T) {
 	t.Parallel()
 	t.Run("when there is an invalid field value in event", func(t *testing.T) {
@@ -200,6 +379,104 @@ func TestWriter_Send(t *testing.T) {
 		})
 		is.NotNil(err, "it should return an error")
 	})
+	t.Run("when the request return an invalid event", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		c := &http.Client{}
+		activateNonDefault(c)
+		url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+		var bodies []string
+		httpmock.RegisterResponder("POST", url, func(request *http.Request) (response *http.Response, err error) {
+			body, _ := ioutil.ReadAll(request.Body)
+			bodies = append(bodies, string(body))
+			if len(bodies) == 1 {
+				return httpmock.NewStringResponse(400, `{"text":"Invalid data format","code":6,"invalid-event-number":1}`), nil
+			}
+			return httpmock.NewBytesResponse(200, nil), nil
+		})
+		subject := &Writer{
+			address:    url,
+			client:     c,
+			marshaller: json.New(),
+		}
+		err := subject.send([]interface{}{1, 2, 3, 4})
+		is.Nil(err, "it should return no error")
+		is.Equal([]string{"1\n2\n3\n4", "3\n4"}, bodies, "it should resend only the events after the invalid one")
+	})
+	t.Run("when the events after the invalid one fail", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		c := &http.Client{}
+		activateNonDefault(c)
+		url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+		var requests int
+		httpmock.RegisterResponder("POST", url, func(request *http.Request) (response *http.Response, err error) {
+			requests++
+			if requests == 1 {
+				return httpmock.NewStringResponse(400, `{"text":"Invalid data format","code":6,"invalid-event-number":1}`), nil
+			}
+			return httpmock.NewBytesResponse(503, nil), nil
+		})
+		subject := &Writer{
+			address:      url,
+			client:       c,
+			marshaller:   json.New(),
+			errorHandler: func(ErrorEvent) {},
+		}
+		err := subject.send([]interface{}{1, 2, 3, 4})
+		is.IsType(&buffer.PartialError{}, err, "it should return a partial error")
+		is.Equal([]interface{}{3, 4}, err.(*buffer.PartialError).Remaining, "it should return the events not sent")
+		is.True(err.(*buffer.PartialError).Retryable(), "it should keep the failure retryable")
+	})
+	t.Run("when the request return a non retryable error", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		c := &http.Client{}
+		activateNonDefault(c)
+		url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+		httpmock.RegisterResponder("POST", url, func(request *http.Request) (response *http.Response, err error) {
+			return httpmock.NewStringResponse(403, `{"text":"Invalid token","code":4}`), nil
+		})
+		var reported []ErrorEvent
+		subject := &Writer{
+			address:    url,
+			client:     c,
+			marshaller: json.New(),
+			errorHandler: func(e ErrorEvent) {
+				reported = append(reported, e)
+			},
+		}
+		err := subject.send([]interface{}{1})
+		is.IsType(&HECError{}, err, "it should return a HEC error")
+		is.False(err.(*HECError).Retryable(), "it should not be retryable")
+		is.Equal([]ErrorEvent{{
+			Stage:     buffer.StageSend,
+			BatchSize: 1,
+			Status:    403,
+			Err:       err,
+		}}, reported, "it should report the error")
+	})
+	t.Run("when there is an observer", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		c := &http.Client{}
+		activateNonDefault(c)
+		url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+		httpmock.RegisterResponder("POST", url, func(request *http.Request) (response *http.Response, err error) {
+			return httpmock.NewBytesResponse(200, nil), nil
+		})
+		observer := &observer{}
+		subject := &Writer{
+			address:    url,
+			client:     c,
+			marshaller: json.New(),
+			observer:   observer,
+		}
+		err := subject.send([]interface{}{1, 2})
+		is.Nil(err, "it should return no error")
+		is.Equal([]int{2}, observer.events, "it should observe the batch")
+		is.Equal([]int{3}, observer.bytes, "it should observe the body size")
+	})
 	t.Run("when the request return 201", func(t *testing.T) {
 		t.Parallel()
 		is := assert.New(t)
@@ -230,6 +507,57 @@ func TestWriter_Send(t *testing.T) {
 	})
 }
 
+func TestWriter_Send_Partial(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	c := &http.Client{}
+	activateNonDefault(c)
+	url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+	var bodies []string
+	httpmock.RegisterResponder("POST", url, func(request *http.Request) (response *http.Response, err error) {
+		body, _ := ioutil.ReadAll(request.Body)
+		bodies = append(bodies, string(body))
+		switch len(bodies) {
+		case 1:
+			return httpmock.NewStringResponse(400, `{"text":"Invalid data format","code":6,"invalid-event-number":1}`), nil
+		case 2:
+			return httpmock.NewBytesResponse(503, nil), nil
+		}
+		return httpmock.NewBytesResponse(200, nil), nil
+	})
+	subject := &Writer{
+		address:      url,
+		client:       c,
+		marshaller:   json.New(),
+		errorHandler: func(ErrorEvent) {},
+	}
+	subject.buffer = buffer.New(buffer.Config{
+		OnOverflow: subject.send,
+		BackOff:    time.Millisecond,
+		Expiration: time.Minute,
+		Cap:        10,
+	})
+	for i := 1; i <= 4; i++ {
+		subject.buffer.Write(i)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	dropped, err := subject.buffer.Flush(ctx)
+	is.Nil(err, "it should return no error")
+	is.Equal(0, dropped, "it should drop nothing")
+	is.Equal([]string{"1\n2\n3\n4", "3\n4", "3\n4"}, bodies, "it should retry only the events after the invalid one")
+}
+
+type observer struct {
+	events []int
+	bytes  []int
+}
+
+func (o *observer) ObserveBatch(events int, bytes int, latency time.Duration, err error) {
+	o.events = append(o.events, events)
+	o.bytes = append(o.bytes, bytes)
+}
+
 var lock sync.Mutex
 
 func activateNonDefault(c *http.Client) {
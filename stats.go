@@ -0,0 +1,144 @@
+package splunk
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DroppedByOverflow = "overflow"
+	DroppedByClosed   = "closed"
+	DroppedByInvalid  = "invalid"
+	DroppedByProcess  = "process"
+)
+
+type Stats struct {
+	Received    uint64
+	Filtered    uint64
+	Batches     uint64
+	Events      uint64
+	Bytes       uint64
+	Failures    map[int]uint64
+	Retries     uint64
+	RetriedBy   map[string]uint64
+	Dropped     uint64
+	DroppedBy   map[string]uint64
+	QueueDepth  int
+	LastSuccess time.Time
+	LastFailure time.Time
+	LastError   error
+	Endpoints   []EndpointStats
+	Circuit     CircuitState
+}
+
+type Observer interface {
+	ObserveBatch(events int, bytes int, latency time.Duration, err error)
+}
+
+type stats struct {
+	sync.Mutex
+	received    uint64
+	filtered    uint64
+	batches     uint64
+	events      uint64
+	bytes       uint64
+	dropped     map[string]uint64
+	failures    map[int]uint64
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   error
+}
+
+func (s *stats) receive(filtered bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.received++
+	if filtered {
+		s.filtered++
+	}
+}
+
+func (s *stats) success(events int, bytes int) {
+	s.Lock()
+	defer s.Unlock()
+	s.batches++
+	s.events += uint64(events)
+	s.bytes += uint64(bytes)
+	s.lastSuccess = time.Now()
+}
+
+func (s *stats) failure(status int, err error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.failures == nil {
+		s.failures = map[int]uint64{}
+	}
+	s.failures[status]++
+	s.lastFailure = time.Now()
+	s.lastError = err
+}
+
+func (s *stats) drop(reason string, events int) {
+	s.Lock()
+	defer s.Unlock()
+	if s.dropped == nil {
+		s.dropped = map[string]uint64{}
+	}
+	s.dropped[reason] += uint64(events)
+}
+
+func (sw *Writer) Stats() Stats {
+	sw.stats.Lock()
+	stats := Stats{
+		Received:    sw.stats.received,
+		Filtered:    sw.stats.filtered,
+		Batches:     sw.stats.batches,
+		Events:      sw.stats.events,
+		Bytes:       sw.stats.bytes,
+		Failures:    make(map[int]uint64, len(sw.stats.failures)),
+		RetriedBy:   map[string]uint64{},
+		DroppedBy:   map[string]uint64{},
+		LastSuccess: sw.stats.lastSuccess,
+		LastFailure: sw.stats.lastFailure,
+		LastError:   sw.stats.lastError,
+	}
+	for status, count := range sw.stats.failures {
+		stats.Failures[status] = count
+	}
+	for reason, count := range sw.stats.dropped {
+		stats.DroppedBy[reason] = count
+	}
+	sw.stats.Unlock()
+
+	for _, count := range sw.Dropped() {
+		stats.DroppedBy[DroppedByOverflow] += count
+	}
+	stats.QueueDepth = sw.writing.Len() + sw.acks.unacked()
+	if sw.buffer != nil {
+		b := sw.buffer.Stats()
+		stats.Retries = uint64(b.Retries)
+		for reason, count := range b.RetriedBy {
+			stats.RetriedBy[reason] += uint64(count)
+		}
+		for reason, count := range b.DroppedBy {
+			stats.DroppedBy[reason] += uint64(count)
+		}
+		stats.QueueDepth += b.Pending
+	}
+	for _, count := range stats.DroppedBy {
+		stats.Dropped += count
+	}
+	stats.Endpoints = sw.endpoints.stats()
+	stats.Circuit = sw.circuit.current()
+	return stats
+}
+
+func (sw *Writer) Healthy() bool {
+	if atomic.LoadInt32(&sw.closed) == 1 {
+		return false
+	}
+	sw.stats.Lock()
+	defer sw.stats.Unlock()
+	return sw.stats.lastFailure.IsZero() || sw.stats.lastSuccess.After(sw.stats.lastFailure)
+}
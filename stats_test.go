@@ -0,0 +1,82 @@
+package splunk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/icrowley/fake"
+	"github.com/jarcoal/httpmock"
+	"github.com/mralves/tracer"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+	"github.com/mundipagg/tracer-splunk-writer/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWriter_Stats(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	c := &http.Client{}
+	activateNonDefault(c)
+	url := "http://log.io/" + fake.Password(8, 8, false, false, false)
+	statuses := []int{200, 503, 0}
+	httpmock.RegisterResponder("POST", url, func(request *http.Request) (response *http.Response, err error) {
+		status := statuses[0]
+		statuses = statuses[1:]
+		if status == 0 {
+			return nil, errors.New("failed")
+		}
+		return httpmock.NewBytesResponse(status, nil), nil
+	})
+	written := make(chan struct{}, 1)
+	buf := &buffer.Mock{}
+	buf.On("Write", mock.Anything).Run(func(args mock.Arguments) {
+		written <- struct{}{}
+	}).Return()
+	buf.On("Stats").Return(buffer.Stats{
+		Pending:   3,
+		Dropped:   3,
+		DroppedBy: map[string]int64{buffer.DroppedByRetries: 2, buffer.DroppedByOversized: 1},
+		Retries:   5,
+		RetriedBy: map[string]int64{"status_503": 4, buffer.DefaultReason: 1},
+	})
+	subject := &Writer{
+		address:    url,
+		client:     c,
+		marshaller: json.New(),
+		buffer:     buf,
+	}
+	subject.SetMinimumLevel(tracer.Warning)
+	is.True(subject.Healthy(), "it should be healthy before sending anything")
+
+	subject.Write(tracer.Entry{Level: tracer.Error, Message: "Message"})
+	subject.Write(tracer.Entry{Level: tracer.Debug, Message: "Message"})
+	<-written
+	_ = subject.writing.Wait(context.Background())
+	is.Nil(subject.send([]interface{}{1, 2}), "it should send the first batch")
+	is.True(subject.Healthy(), "it should be healthy after a success")
+	is.NotNil(subject.send([]interface{}{3}), "it should fail the second batch")
+	is.NotNil(subject.send([]interface{}{4}), "it should fail the third batch")
+	is.False(subject.Healthy(), "it should be unhealthy after a failure")
+
+	actual := subject.Stats()
+	is.Equal(uint64(2), actual.Received, "it should count the entries received")
+	is.Equal(uint64(1), actual.Filtered, "it should count the entries filtered")
+	is.Equal(uint64(1), actual.Batches, "it should count the batches sent")
+	is.Equal(uint64(2), actual.Events, "it should count the events sent")
+	is.Equal(uint64(3), actual.Bytes, "it should count the bytes sent")
+	is.Equal(map[int]uint64{503: 1, 0: 1}, actual.Failures, "it should count the failures by status")
+	is.Equal(uint64(5), actual.Retries, "it should return the buffer retries")
+	is.Equal(map[string]uint64{"status_503": 4, buffer.DefaultReason: 1}, actual.RetriedBy, "it should return the buffer retries by reason")
+	is.Equal(uint64(3), actual.Dropped, "it should return the dropped events")
+	is.Equal(map[string]uint64{
+		buffer.DroppedByRetries:   2,
+		buffer.DroppedByOversized: 1,
+	}, actual.DroppedBy, "it should return the dropped events by reason")
+	is.Equal(3, actual.QueueDepth, "it should return the queue depth")
+	is.False(actual.LastSuccess.IsZero(), "it should return the last success")
+	is.True(actual.LastFailure.After(actual.LastSuccess), "it should return the last failure")
+	is.NotNil(actual.LastError, "it should return the last error")
+}
@@ -0,0 +1,30 @@
+package splunk
+
+import (
+	"time"
+)
+
+type TimestampFormat uint8
+
+const (
+	EpochSeconds TimestampFormat = iota
+	EpochMillis
+	RFC3339
+)
+
+const TimestampKey = "Timestamp"
+
+func timestamp(t time.Time) float64 {
+	return float64(t.UnixNano()/int64(time.Millisecond)) / 1000
+}
+
+func eventTimestamp(t time.Time, format TimestampFormat) (interface{}, bool) {
+	switch format {
+	case EpochMillis:
+		return t.UnixNano() / int64(time.Millisecond), true
+	case RFC3339:
+		return t.UTC().Format(time.RFC3339Nano), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,32 @@
+package splunk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestamp(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	ref := time.Unix(1570000000, int64(123456789))
+	is.Equal(1570000000.123, timestamp(ref), "it should return the epoch seconds with milliseconds")
+}
+
+func TestEventTimestamp(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+	ref := time.Unix(1570000000, int64(123456789))
+	cases := map[TimestampFormat]interface{}{
+		EpochMillis: int64(1570000000123),
+		RFC3339:     "2019-10-02T07:06:40.123456789Z",
+	}
+	for input, expected := range cases {
+		actual, ok := eventTimestamp(ref, input)
+		is.True(ok, "it should add the timestamp to the event")
+		is.Equal(expected, actual, "it should return the expected value")
+	}
+	_, ok := eventTimestamp(ref, EpochSeconds)
+	is.False(ok, "it should not add the timestamp to the event")
+}
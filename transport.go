@@ -0,0 +1,113 @@
+package splunk
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type TLS struct {
+	CAFile             string
+	CAPEM              string
+	CertFile           string
+	KeyFile            string
+	CertPEM            string
+	KeyPEM             string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+type Transport struct {
+	Proxy               string
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+}
+
+func (t TLS) config() (*tls.Config, error) {
+	if t == (TLS{}) {
+		return nil, nil
+	}
+	config := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if len(t.CAFile) > 0 || len(t.CAPEM) > 0 {
+		pool := x509.NewCertPool()
+		if len(t.CAFile) > 0 {
+			pem, err := ioutil.ReadFile(t.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("could not read the CA file: %v", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificate found in the CA file %v", t.CAFile)
+			}
+		}
+		if len(t.CAPEM) > 0 && !pool.AppendCertsFromPEM([]byte(t.CAPEM)) {
+			return nil, errors.New("no certificate found in the CA PEM")
+		}
+		config.RootCAs = pool
+	}
+	var (
+		certificate tls.Certificate
+		err         error
+	)
+	switch {
+	case len(t.CertFile) > 0 || len(t.KeyFile) > 0:
+		certificate, err = tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	case len(t.CertPEM) > 0 || len(t.KeyPEM) > 0:
+		certificate, err = tls.X509KeyPair([]byte(t.CertPEM), []byte(t.KeyPEM))
+	default:
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not load the client certificate: %v", err)
+	}
+	config.Certificates = []tls.Certificate{certificate}
+	return config, nil
+}
+
+func (t Transport) proxy() (func(*http.Request) (*url.URL, error), error) {
+	if len(t.Proxy) == 0 {
+		return http.ProxyFromEnvironment, nil
+	}
+	proxy, err := url.Parse(t.Proxy)
+	if err != nil {
+		return http.ProxyFromEnvironment, fmt.Errorf("invalid proxy: %v", err)
+	}
+	return http.ProxyURL(proxy), nil
+}
+
+func newClient(config Config) (*http.Client, error) {
+	if config.HTTPClient != nil {
+		return config.HTTPClient, nil
+	}
+	idle := config.Transport.IdleConnTimeout
+	if idle == 0 {
+		idle = config.Timeout
+	}
+	transport := &http.Transport{
+		TLSHandshakeTimeout: config.Timeout,
+		IdleConnTimeout:     idle,
+		MaxIdleConns:        config.Transport.MaxIdleConns,
+		MaxIdleConnsPerHost: config.Transport.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     config.Transport.MaxConnsPerHost,
+	}
+	client := &http.Client{
+		Timeout:   config.Timeout,
+		Transport: transport,
+	}
+	proxy, err := config.Transport.proxy()
+	transport.Proxy = proxy
+	if err != nil {
+		return client, err
+	}
+	transport.TLSClientConfig, err = config.TLS.config()
+	return client, err
+}
@@ -0,0 +1,163 @@
+package splunk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func certificatePEM(certificate *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+}
+
+func clientCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			w.Header().Set("X-Client", r.TLS.PeerCertificates[0].Subject.CommonName)
+		}
+	}))
+	defer server.Close()
+	ca := certificatePEM(server.Certificate())
+	caFile := filepath.Join(os.TempDir(), "splunk-ca-"+time.Now().Format("150405.000000000")+".pem")
+	_ = ioutil.WriteFile(caFile, []byte(ca), 0600)
+	defer os.Remove(caFile)
+
+	t.Run("when the CA is unknown", func(t *testing.T) {
+		is := assert.New(t)
+		client, err := newClient(Config{})
+		is.Nil(err, "it should return no error")
+		_, err = client.Get(server.URL)
+		is.NotNil(err, "it should not trust the server")
+	})
+	t.Run("when the CA is given as PEM", func(t *testing.T) {
+		is := assert.New(t)
+		client, err := newClient(Config{TLS: TLS{CAPEM: ca}})
+		is.Nil(err, "it should return no error")
+		response, err := client.Get(server.URL)
+		is.Nil(err, "it should trust the server")
+		if response != nil {
+			response.Body.Close()
+		}
+	})
+	t.Run("when the CA is given as a file", func(t *testing.T) {
+		is := assert.New(t)
+		client, err := newClient(Config{TLS: TLS{CAFile: caFile}})
+		is.Nil(err, "it should return no error")
+		response, err := client.Get(server.URL)
+		is.Nil(err, "it should trust the server")
+		if response != nil {
+			response.Body.Close()
+		}
+	})
+	t.Run("when the verification is disabled", func(t *testing.T) {
+		is := assert.New(t)
+		client, err := newClient(Config{TLS: TLS{InsecureSkipVerify: true}})
+		is.Nil(err, "it should return no error")
+		response, err := client.Get(server.URL)
+		is.Nil(err, "it should accept the server")
+		if response != nil {
+			response.Body.Close()
+		}
+	})
+	t.Run("when there is a client certificate", func(t *testing.T) {
+		is := assert.New(t)
+		cert, key := clientCertificate(t)
+		mtls := httptest.NewUnstartedServer(server.Config.Handler)
+		mtls.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		mtls.StartTLS()
+		defer mtls.Close()
+		client, err := newClient(Config{TLS: TLS{
+			CAPEM:   certificatePEM(mtls.Certificate()),
+			CertPEM: cert,
+			KeyPEM:  key,
+		}})
+		is.Nil(err, "it should return no error")
+		response, err := client.Get(mtls.URL)
+		is.Nil(err, "it should be accepted by the server")
+		if response != nil {
+			is.Equal("client", response.Header.Get("X-Client"), "it should present the client certificate")
+			response.Body.Close()
+		}
+	})
+	t.Run("when the TLS configuration is invalid", func(t *testing.T) {
+		is := assert.New(t)
+		cases := []TLS{
+			{CAFile: filepath.Join(os.TempDir(), "missing.pem")},
+			{CAPEM: "not a certificate"},
+			{CertPEM: "not a certificate", KeyPEM: "not a key"},
+			{CertFile: "missing.pem", KeyFile: "missing.key"},
+		}
+		for _, c := range cases {
+			client, err := newClient(Config{TLS: c})
+			is.NotNil(err, "it should return an error")
+			is.NotNil(client, "it should return a client")
+		}
+	})
+	t.Run("when there is a custom client", func(t *testing.T) {
+		is := assert.New(t)
+		custom := &http.Client{}
+		client, err := newClient(Config{HTTPClient: custom, TLS: TLS{CAPEM: "ignored"}})
+		is.Nil(err, "it should return no error")
+		is.Equal(custom, client, "it should use the custom client")
+	})
+	t.Run("when there are connection settings", func(t *testing.T) {
+		is := assert.New(t)
+		client, err := newClient(Config{
+			Timeout: time.Second,
+			Transport: Transport{
+				Proxy:               "http://proxy:3128",
+				MaxIdleConns:        10,
+				MaxIdleConnsPerHost: 5,
+				MaxConnsPerHost:     20,
+			},
+		})
+		is.Nil(err, "it should return no error")
+		transport := client.Transport.(*http.Transport)
+		is.Equal(5, transport.MaxIdleConnsPerHost, "it should set the idle connections per host")
+		is.Equal(10, transport.MaxIdleConns, "it should set the idle connections")
+		is.Equal(20, transport.MaxConnsPerHost, "it should set the connections per host")
+		is.Equal(time.Second, transport.IdleConnTimeout, "it should use the timeout for idle connections")
+		request, _ := http.NewRequest(http.MethodPost, "https://splunk:8088", nil)
+		proxy, _ := transport.Proxy(request)
+		is.Equal("http://proxy:3128", proxy.String(), "it should use the proxy")
+	})
+}
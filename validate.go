@@ -0,0 +1,157 @@
+package splunk
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mralves/tracer"
+)
+
+var ErrOnlyFatal = errors.New("MinimumLevel is 0 (FATAL), every other entry is filtered")
+
+type ValidationError struct {
+	Problems []error
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		problems = append(problems, problem.Error())
+	}
+	return "invalid config: " + strings.Join(problems, "; ")
+}
+
+type validation struct {
+	problems []error
+}
+
+func (v *validation) fail(field string, value interface{}, err error) {
+	v.problems = append(v.problems, &ConfigError{Field: field, Value: value, Err: err})
+}
+
+func (v *validation) address(field string, address string) {
+	u, err := url.Parse(address)
+	switch {
+	case err != nil:
+		v.fail(field, address, errors.New("expected an URL"))
+	case u.Scheme != "http" && u.Scheme != "https":
+		v.fail(field, address, errors.New("expected an http or https URL"))
+	case len(u.Host) == 0:
+		v.fail(field, address, errors.New("expected the host of Splunk"))
+	}
+}
+
+func (v *validation) level(field string, level uint8) {
+	if level > tracer.Debug {
+		v.fail(field, level, fmt.Errorf("expected a level between %v (fatal) and %v (debug)", tracer.Fatal, tracer.Debug))
+	}
+}
+
+func (v *validation) duration(field string, d time.Duration) {
+	if d < 0 {
+		v.fail(field, d, errors.New("expected a positive duration"))
+	}
+}
+
+func (v *validation) size(field string, n int) {
+	if n < 0 {
+		v.fail(field, n, errors.New("expected a positive number"))
+	}
+}
+
+func (v *validation) enum(field string, value uint8, max uint8) {
+	if value > max {
+		v.fail(field, value, fmt.Errorf("expected a value between 0 and %v", max))
+	}
+}
+
+// Validate cannot tell an unset MinimumLevel from tracer.Fatal, both are 0,
+// New reports ErrOnlyFatal to the ErrorHandler instead.
+func (c Config) Validate() error {
+	v := &validation{}
+	if len(c.Address) == 0 && len(c.Addresses) == 0 {
+		v.problems = append(v.problems, &ConfigError{Field: "Address", Err: errors.New("is required")})
+	}
+	if len(c.Address) > 0 {
+		v.address("Address", c.Address)
+	}
+	for i, address := range c.Addresses {
+		v.address(fmt.Sprintf("Addresses[%v]", i), address)
+	}
+
+	v.level("MinimumLevel", c.MinimumLevel)
+	for prefix, level := range c.Levels {
+		if len(strings.Trim(prefix, ".")) == 0 {
+			v.fail("Levels", prefix, errors.New("expected a logger name"))
+		}
+		v.level("Levels."+prefix, level)
+	}
+	v.level("Enrichment.StackTraceLevel", c.Enrichment.StackTraceLevel)
+	v.level("Queue.Level", c.Queue.Level)
+
+	v.duration("Timeout", c.Timeout)
+	v.duration("Buffer.Expiration", c.Buffer.Expiration)
+	v.duration("Buffer.BackOff", c.Buffer.BackOff)
+	v.duration("Buffer.Spool.MaxAge", c.Buffer.Spool.MaxAge)
+	v.duration("Buffer.Spool.ReplayInterval", c.Buffer.Spool.ReplayInterval)
+	v.duration("Ack.Interval", c.Ack.Interval)
+	v.duration("Ack.Timeout", c.Ack.Timeout)
+	v.duration("Balancing.ProbeInterval", c.Balancing.ProbeInterval)
+	v.duration("CircuitBreaker.CoolDown", c.CircuitBreaker.CoolDown)
+	v.duration("Transport.IdleConnTimeout", c.Transport.IdleConnTimeout)
+	v.duration("Reload.Interval", c.Reload.Interval)
+
+	v.size("Buffer.Cap", c.Buffer.Cap)
+	v.size("Buffer.OnWait", c.Buffer.OnWait)
+	v.size("Buffer.Workers", c.Buffer.Workers)
+	v.size("Buffer.MaxBytes", c.Buffer.MaxBytes)
+	if c.Buffer.Spool.MaxBytes < 0 {
+		v.fail("Buffer.Spool.MaxBytes", c.Buffer.Spool.MaxBytes, errors.New("expected a positive number"))
+	}
+	v.size("Queue.Capacity", c.Queue.Capacity)
+	v.size("Queue.Workers", c.Queue.Workers)
+	v.size("Balancing.MaxFailures", c.Balancing.MaxFailures)
+	v.size("CircuitBreaker.Threshold", c.CircuitBreaker.Threshold)
+	v.size("Transport.MaxIdleConns", c.Transport.MaxIdleConns)
+	v.size("Transport.MaxIdleConnsPerHost", c.Transport.MaxIdleConnsPerHost)
+	v.size("Transport.MaxConnsPerHost", c.Transport.MaxConnsPerHost)
+	if c.Compression.Level < gzip.HuffmanOnly || c.Compression.Level > gzip.BestCompression {
+		v.fail("Compression.Level", c.Compression.Level, fmt.Errorf("expected a level between %v and %v", gzip.HuffmanOnly, gzip.BestCompression))
+	}
+
+	v.enum("TimestampFormat", uint8(c.TimestampFormat), uint8(RFC3339))
+	v.enum("Queue.Overflow", uint8(c.Queue.Overflow), uint8(Block))
+	v.enum("Encoding", uint8(c.Encoding), uint8(JSONArray))
+	v.enum("Balancing.Selection", uint8(c.Balancing.Selection), uint8(LeastFailures))
+	v.enum("Oversized", uint8(c.Oversized), uint8(TruncateOversized))
+
+	for i, rule := range c.Redaction {
+		v.enum(fmt.Sprintf("Redaction[%v].Strategy", i), uint8(rule.Strategy), uint8(Remove))
+		if rule.Strategy == Hash && len(rule.HashKey) == 0 {
+			v.problems = append(v.problems, &ConfigError{Field: fmt.Sprintf("Redaction[%v].HashKey", i), Err: errMissingHashKey})
+		}
+		for j, key := range rule.Keys {
+			if _, err := keyMatcher(key); err != nil {
+				v.fail(fmt.Sprintf("Redaction[%v].Keys[%v]", i, j), key, err)
+			}
+		}
+	}
+
+	if c.HTTPClient == nil {
+		if _, err := c.Transport.proxy(); err != nil {
+			v.fail("Transport.Proxy", c.Transport.Proxy, err)
+		}
+		if _, err := c.TLS.config(); err != nil {
+			v.problems = append(v.problems, &ConfigError{Field: "TLS", Err: err})
+		}
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
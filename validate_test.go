@@ -0,0 +1,156 @@
+package splunk
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mralves/tracer"
+	"github.com/mundipagg/tracer-splunk-writer/buffer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+	valid := func() Config {
+		return Config{
+			Address:      "https://splunk:8088/services/collector",
+			MinimumLevel: tracer.Informational,
+		}
+	}
+	t.Run("when the config is valid", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		config := valid()
+		config.Addresses = []string{"http://other:8088/services/collector"}
+		config.Levels = map[string]uint8{"payments": tracer.Debug}
+		config.Buffer.MaxRetries = -1
+		config.Compression.Level = 9
+		is.Nil(config.Validate(), "it should return no error")
+	})
+	cases := map[string]struct {
+		change  func(c *Config)
+		message string
+	}{
+		"the address is missing": {
+			change:  func(c *Config) { c.Address = "" },
+			message: "Address: is required",
+		},
+		"the address is not an URL": {
+			change:  func(c *Config) { c.Address = "localhost:8088" },
+			message: `invalid value "localhost:8088" for Address: expected an http or https URL`,
+		},
+		"an address has no host": {
+			change:  func(c *Config) { c.Addresses = []string{"http://"} },
+			message: `invalid value "http://" for Addresses[0]: expected the host of Splunk`,
+		},
+		"the minimum level is unknown": {
+			change:  func(c *Config) { c.MinimumLevel = 8 },
+			message: `invalid value "8" for MinimumLevel: expected a level between 0 (fatal) and 7 (debug)`,
+		},
+		"a logger level is unknown": {
+			change:  func(c *Config) { c.Levels = map[string]uint8{"payments": 9} },
+			message: `invalid value "9" for Levels.payments`,
+		},
+		"a logger name is empty": {
+			change:  func(c *Config) { c.Levels = map[string]uint8{".": tracer.Debug} },
+			message: `invalid value "." for Levels: expected a logger name`,
+		},
+		"a duration is negative": {
+			change:  func(c *Config) { c.Buffer.Expiration = -time.Second },
+			message: `invalid value "-1s" for Buffer.Expiration: expected a positive duration`,
+		},
+		"a buffer size is negative": {
+			change:  func(c *Config) { c.Buffer.Cap = -1 },
+			message: `invalid value "-1" for Buffer.Cap: expected a positive number`,
+		},
+		"the compression level is unknown": {
+			change:  func(c *Config) { c.Compression.Level = 10 },
+			message: `invalid value "10" for Compression.Level`,
+		},
+		"an enumeration is unknown": {
+			change:  func(c *Config) { c.Queue.Overflow = 9 },
+			message: `invalid value "9" for Queue.Overflow: expected a value between 0 and 3`,
+		},
+		"a redaction strategy is unknown": {
+			change:  func(c *Config) { c.Redaction = []RedactionRule{{Keys: []string{"cvv"}, Strategy: 9}} },
+			message: `invalid value "9" for Redaction[0].Strategy: expected a value between 0 and 3`,
+		},
+		"a redaction key is invalid": {
+			change:  func(c *Config) { c.Redaction = []RedactionRule{{Keys: []string{"/[/"}}} },
+			message: `invalid value "/[/" for Redaction[0].Keys[0]: invalid redaction pattern`,
+		},
+		"a redaction hash has no key": {
+			change:  func(c *Config) { c.Redaction = []RedactionRule{{Keys: []string{"document"}, Strategy: Hash}} },
+			message: "Redaction[0].HashKey: the Hash strategy requires a HashKey",
+		},
+		"the TLS config is invalid": {
+			change:  func(c *Config) { c.TLS.CAPEM = "not a certificate" },
+			message: "TLS: no certificate found in the CA PEM",
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run("when "+name, func(t *testing.T) {
+			t.Parallel()
+			is := assert.New(t)
+			config := valid()
+			c.change(&config)
+			err := config.Validate()
+			is.IsType(&ValidationError{}, err, "it should return a validation error")
+			if err != nil {
+				is.Contains(err.Error(), c.message, "it should describe the problem")
+			}
+		})
+	}
+	t.Run("when there are many problems", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		config := Config{Timeout: -time.Second, Buffer: buffer.Config{Workers: -1}}
+		err := config.Validate()
+		is.Len(err.(*ValidationError).Problems, 3, "it should return every problem")
+	})
+	t.Run("when there is a custom client", func(t *testing.T) {
+		t.Parallel()
+		is := assert.New(t)
+		config := valid()
+		config.HTTPClient = &http.Client{}
+		config.TLS.CAPEM = "ignored"
+		is.Nil(config.Validate(), "it should not validate the TLS config")
+	})
+}
+
+func TestNew_MinimumLevel(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		config   Config
+		reported []ErrorEvent
+	}{
+		"when the minimum level is not set": {
+			config:   Config{},
+			reported: []ErrorEvent{{Stage: buffer.StageConfig, Err: ErrOnlyFatal}},
+		},
+		"when the minimum level is set": {
+			config: Config{MinimumLevel: tracer.Warning},
+		},
+		"when there are levels by logger": {
+			config: Config{Levels: map[string]uint8{"payments": tracer.Debug}},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := assert.New(t)
+			var reported []ErrorEvent
+			c.config.Address = "http://localhost:8088/services/collector"
+			c.config.ErrorHandler = func(e ErrorEvent) {
+				reported = append(reported, e)
+			}
+			subject := New(c.config)
+			defer subject.Close(context.Background())
+			is.Equal(c.reported, reported, "it should report a writer that only sends fatal entries")
+		})
+	}
+}